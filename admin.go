@@ -0,0 +1,286 @@
+package gohalt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	modeauto uint64 = iota
+	modeopen
+	modeclosed
+)
+
+var modes = map[uint64]string{
+	modeauto:   "auto",
+	modeopen:   "open",
+	modeclosed: "closed",
+}
+
+type decision struct {
+	Timestamp time.Time `json:"timestamp"`
+	Key       string    `json:"key,omitempty"`
+	Mode      string    `json:"mode"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type decisions struct {
+	buf  []decision
+	cap  uint8
+	lock sync.Mutex
+}
+
+func (d *decisions) Push(dec decision) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.cap == 0 {
+		return
+	}
+	if len(d.buf) >= int(d.cap) {
+		d.buf = d.buf[1:]
+	}
+	d.buf = append(d.buf, dec)
+}
+
+func (d *decisions) List() []decision {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	buf := make([]decision, len(d.buf))
+	_ = copy(buf, d.buf)
+	return buf
+}
+
+type tadmin struct {
+	thr       Throttler
+	name      string
+	mode      uint64
+	bypassed  uint64
+	decisions *decisions
+}
+
+func (thr *tadmin) Acquire(ctx context.Context) error {
+	bypassed, err := thr.acquire(ctx, thr.thr.Acquire)
+	if bypassed {
+		atomicBIncr(&thr.bypassed)
+	}
+	return err
+}
+
+func (thr *tadmin) Release(ctx context.Context) error {
+	// releases that match bypassed acquires
+	// are never forwarded to underlying throttler
+	if atomicCDecr(&thr.bypassed) {
+		return nil
+	}
+	return thr.thr.Release(ctx)
+}
+
+// acquire returns whether the call has bypassed underlying throttler
+// because of forced mode and acquire error if any.
+func (thr *tadmin) acquire(ctx context.Context, acquire Runnable) (bypassed bool, err error) {
+	mode := atomicGet(&thr.mode)
+	switch mode {
+	case modeopen:
+		bypassed = true
+	case modeclosed:
+		bypassed = true
+		err = errors.New("throttler has been forced closed")
+	default:
+		err = acquire(ctx)
+	}
+	dec := decision{Timestamp: time.Now().UTC(), Key: ctxKey(ctx), Mode: modes[mode]}
+	if err != nil {
+		dec.Error = err.Error()
+	}
+	thr.decisions.Push(dec)
+	return bypassed, err
+}
+
+func (thr *tadmin) Meta() map[string]interface{} {
+	if tunable, ok := thr.thr.(Tunable); ok {
+		return tunable.Meta()
+	}
+	return nil
+}
+
+func (thr *tadmin) Tune(param string, value float64) error {
+	if tunable, ok := thr.thr.(Tunable); ok {
+		return tunable.Tune(param, value)
+	}
+	return untunable(param)
+}
+
+func (thr *tadmin) Reset() {
+	if tunable, ok := thr.thr.(Tunable); ok {
+		tunable.Reset()
+	}
+}
+
+// Admin defines embedded json http admin endpoint that lists registered named throttlers,
+// shows their live meta and recent decisions and lets operators control them on the fly.
+// Admin exposes following routes relative to its mount point:
+// - `GET /` lists all registered throttlers.
+// - `GET /{name}` shows single throttler live meta and recent decisions.
+// - `POST /{name}/tune` tunes throttler limit with `{"param": "threshold", "value": 10}` body.
+// - `POST /{name}/open` forces throttler to never throttle.
+// - `POST /{name}/close` forces throttler to always throttle.
+// - `POST /{name}/auto` puts throttler back under its own control.
+// - `POST /{name}/reset` resets throttler internal state.
+// Use `http.StripPrefix` to mount admin on existing mux under custom prefix.
+type Admin interface {
+	http.Handler
+	// Register wraps the provided throttler into admin controlled throttler
+	// registered under the provided name, returned throttler needs to be used instead of the provided one.
+	Register(name string, thr Throttler) Throttler
+}
+
+type admin struct {
+	thrs     map[string]*tadmin
+	capacity uint8
+	lock     sync.RWMutex
+}
+
+// NewAdmin creates new admin endpoint instance
+// that keeps recent decisions of each registered throttler
+// in bounded buffer with capacity c defined by the specified capacity.
+// Releases of calls acquired while throttler is forced open or closed
+// are never forwarded to underlying throttler,
+// tickets and runners match each release to its own acquire,
+// while plain releases are matched in order so each acquire needs exactly one release.
+// Tune rejects NaN, infinite and out of range values of known params with distinct error.
+// Only `Tunable` throttlers expose live meta and could be tuned and reset.
+func NewAdmin(capacity uint8) Admin {
+	return &admin{thrs: make(map[string]*tadmin), capacity: capacity}
+}
+
+func (adm *admin) Register(name string, thr Throttler) Throttler {
+	tadmin := &tadmin{thr: thr, name: name, decisions: &decisions{cap: adm.capacity}}
+	adm.lock.Lock()
+	defer adm.lock.Unlock()
+	adm.thrs[name] = tadmin
+	return tadmin
+}
+
+type adminview struct {
+	Name      string                 `json:"name"`
+	Mode      string                 `json:"mode"`
+	Tunable   bool                   `json:"tunable"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+	Decisions []decision             `json:"decisions,omitempty"`
+}
+
+type admintune struct {
+	Param string  `json:"param"`
+	Value float64 `json:"value"`
+}
+
+func (adm *admin) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(path) == 1 && path[0] == "" && req.Method == http.MethodGet:
+		adm.list(w)
+	case len(path) == 1 && req.Method == http.MethodGet:
+		if thr, ok := adm.find(w, path[0]); ok {
+			adm.write(w, http.StatusOK, adm.view(path[0], thr, true))
+		}
+	case len(path) == 2 && req.Method == http.MethodPost:
+		if thr, ok := adm.find(w, path[0]); ok {
+			adm.control(w, req, path[0], path[1], thr)
+		}
+	default:
+		adm.fail(w, http.StatusNotFound, fmt.Errorf("admin hasn't found any route %s %s", req.Method, req.URL.Path))
+	}
+}
+
+func (adm *admin) list(w http.ResponseWriter) {
+	adm.lock.RLock()
+	names := make([]string, 0, len(adm.thrs))
+	for name := range adm.thrs {
+		names = append(names, name)
+	}
+	adm.lock.RUnlock()
+	sort.Strings(names)
+	views := make([]adminview, 0, len(names))
+	for _, name := range names {
+		if thr, ok := adm.lookup(name); ok {
+			views = append(views, adm.view(name, thr, false))
+		}
+	}
+	adm.write(w, http.StatusOK, views)
+}
+
+func (adm *admin) control(w http.ResponseWriter, req *http.Request, name string, action string, thr *tadmin) {
+	switch action {
+	case "open":
+		atomicSet(&thr.mode, modeopen)
+	case "close":
+		atomicSet(&thr.mode, modeclosed)
+	case "auto":
+		atomicSet(&thr.mode, modeauto)
+	case "reset":
+		thr.Reset()
+	case "tune":
+		var tune admintune
+		if err := json.NewDecoder(req.Body).Decode(&tune); err != nil {
+			adm.fail(w, http.StatusBadRequest, fmt.Errorf("admin hasn't parsed tune body %w", err))
+			return
+		}
+		if err := thr.Tune(tune.Param, tune.Value); err != nil {
+			adm.fail(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		adm.fail(w, http.StatusNotFound, fmt.Errorf("admin hasn't found any action %q", action))
+		return
+	}
+	log("admin action %s has been applied to throttler %s", action, name)
+	adm.write(w, http.StatusOK, adm.view(name, thr, false))
+}
+
+func (adm *admin) lookup(name string) (*tadmin, bool) {
+	adm.lock.RLock()
+	defer adm.lock.RUnlock()
+	thr, ok := adm.thrs[name]
+	return thr, ok
+}
+
+func (adm *admin) find(w http.ResponseWriter, name string) (*tadmin, bool) {
+	thr, ok := adm.lookup(name)
+	if !ok {
+		adm.fail(w, http.StatusNotFound, fmt.Errorf("admin hasn't found any throttler %q", name))
+	}
+	return thr, ok
+}
+
+func (adm *admin) view(name string, thr *tadmin, full bool) adminview {
+	_, tunable := thr.thr.(Tunable)
+	view := adminview{
+		Name:    name,
+		Mode:    modes[atomicGet(&thr.mode)],
+		Tunable: tunable,
+		Meta:    thr.Meta(),
+	}
+	if full {
+		view.Decisions = thr.decisions.List()
+	}
+	return view
+}
+
+func (adm *admin) fail(w http.ResponseWriter, status int, err error) {
+	adm.write(w, status, map[string]string{"error": err.Error()})
+}
+
+func (adm *admin) write(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log("admin response error happened %v", err)
+	}
+}
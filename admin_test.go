@@ -0,0 +1,174 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin(t *testing.T) {
+	table := map[string]struct {
+		thr    Throttler
+		method string
+		path   string
+		body   string
+		status int
+		resp   string
+		err    error
+	}{
+		"Admin should list registered throttlers": {
+			thr:    NewThrottlerAfter(1),
+			method: http.MethodGet,
+			path:   "/",
+			status: http.StatusOK,
+			resp:   `[{"name":"test","mode":"auto","tunable":true,"meta":{"current":1,"threshold":1}}]`,
+		},
+		"Admin should show registered throttler with decisions": {
+			thr:    NewThrottlerEcho(errors.New("test")),
+			method: http.MethodGet,
+			path:   "/test",
+			status: http.StatusOK,
+			resp:   `"error":"test"`,
+			err:    errors.New("test"),
+		},
+		"Admin should fail on unknown throttler": {
+			thr:    NewThrottlerAfter(1),
+			method: http.MethodGet,
+			path:   "/unknown",
+			status: http.StatusNotFound,
+			resp:   `{"error":"admin hasn't found any throttler \"unknown\""}`,
+		},
+		"Admin should fail on unknown action": {
+			thr:    NewThrottlerAfter(1),
+			method: http.MethodPost,
+			path:   "/test/unknown",
+			status: http.StatusNotFound,
+			resp:   `{"error":"admin hasn't found any action \"unknown\""}`,
+		},
+		"Admin should tune tunable throttler": {
+			thr:    NewThrottlerAfter(0),
+			method: http.MethodPost,
+			path:   "/test/tune",
+			body:   `{"param":"threshold","value":5}`,
+			status: http.StatusOK,
+			resp:   `{"name":"test","mode":"auto","tunable":true,"meta":{"current":1,"threshold":5}}`,
+			err:    errors.New("throttler has exceed threshold"),
+		},
+		"Admin should fail to tune untunable throttler": {
+			thr:    NewThrottlerEcho(nil),
+			method: http.MethodPost,
+			path:   "/test/tune",
+			body:   `{"param":"threshold","value":5}`,
+			status: http.StatusBadRequest,
+			resp:   `{"error":"throttler hasn't found any tunable param \"threshold\""}`,
+		},
+		"Admin should fail to tune tunable throttler with invalid value": {
+			thr:    NewThrottlerAfter(1),
+			method: http.MethodPost,
+			path:   "/test/tune",
+			body:   `{"param":"threshold","value":-5}`,
+			status: http.StatusBadRequest,
+			resp:   `{"error":"throttler tunable param \"threshold\" value -5 is invalid"}`,
+		},
+		"Admin should reset tunable throttler": {
+			thr:    NewThrottlerAfter(0),
+			method: http.MethodPost,
+			path:   "/test/reset",
+			status: http.StatusOK,
+			resp:   `{"name":"test","mode":"auto","tunable":true,"meta":{"current":0,"threshold":0}}`,
+			err:    errors.New("throttler has exceed threshold"),
+		},
+		"Admin should force close throttler": {
+			thr:    NewThrottlerEcho(nil),
+			method: http.MethodPost,
+			path:   "/test/close",
+			status: http.StatusOK,
+			resp:   `{"name":"test","mode":"closed","tunable":false}`,
+		},
+		"Admin should force open throttler": {
+			thr:    NewThrottlerEcho(errors.New("test")),
+			method: http.MethodPost,
+			path:   "/test/open",
+			status: http.StatusOK,
+			resp:   `{"name":"test","mode":"open","tunable":false}`,
+			err:    errors.New("test"),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			adm := NewAdmin(4)
+			thr := adm.Register("test", tcase.thr)
+			ctx := WithKey(context.Background(), "key")
+			assert.Equal(t, tcase.err, thr.Acquire(ctx))
+			req := httptest.NewRequest(tcase.method, tcase.path, strings.NewReader(tcase.body))
+			rec := httptest.NewRecorder()
+			adm.ServeHTTP(rec, req)
+			assert.Equal(t, tcase.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), tcase.resp)
+			assert.NoError(t, thr.Release(ctx))
+		})
+	}
+}
+
+func TestAdminModes(t *testing.T) {
+	adm := NewAdmin(0)
+	running := NewThrottlerRunning(1)
+	thr := adm.Register("test", running)
+	ctx := context.Background()
+	serve := func(path string) {
+		rec := httptest.NewRecorder()
+		adm.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.NoError(t, thr.Acquire(ctx))
+	serve("/test/close")
+	assert.Equal(t, errors.New("throttler has been forced closed"), thr.Acquire(ctx))
+	serve("/test/open")
+	assert.NoError(t, thr.Acquire(ctx))
+	serve("/test/auto")
+	assert.Equal(t, errors.New("throttler has exceed running threshold"), thr.Acquire(ctx))
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, thr.Release(ctx))
+	}
+	assert.Equal(t, uint64(0), running.(Tunable).Meta()["running"])
+}
+
+func TestAdminTickets(t *testing.T) {
+	adm := NewAdmin(0)
+	running := NewThrottlerRunning(1)
+	thr := adm.Register("test", running)
+	ctx := context.Background()
+	serve := func(path string) {
+		rec := httptest.NewRecorder()
+		adm.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	serve("/test/close")
+	_, err := AcquireTicket(ctx, thr)
+	assert.Equal(t, errors.New("throttler has been forced closed"), err)
+	serve("/test/auto")
+	tkt, err := AcquireTicket(ctx, thr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), running.(Tunable).Meta()["running"])
+	assert.NoError(t, tkt.Release(ctx))
+	assert.Equal(t, uint64(0), running.(Tunable).Meta()["running"])
+	r := NewRunnerSync(ctx, thr)
+	r.Run(nope)
+	assert.NoError(t, r.Result())
+	assert.Equal(t, uint64(0), running.(Tunable).Meta()["running"])
+	tunable := thr.(Tunable)
+	var terr TuneError
+	assert.True(t, errors.As(tunable.Tune("threshold", math.NaN()), &terr))
+	assert.True(t, terr.Invalid)
+	assert.Equal(t, TuneError{Param: "threshold", Value: math.Inf(1), Invalid: true}, tunable.Tune("threshold", math.Inf(1)))
+	assert.Equal(t, TuneError{Param: "unknown"}, tunable.Tune("unknown", 1))
+	// read only meta params aren't tunable
+	assert.Equal(t, TuneError{Param: "running"}, tunable.Tune("running", 5))
+	assert.NoError(t, tunable.Tune("threshold", 2))
+}
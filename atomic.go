@@ -49,3 +49,15 @@ func atomicSet(number *uint64, value uint64) uint64 {
 func atomicGet(number *uint64) uint64 {
 	return atomic.LoadUint64(number)
 }
+
+func atomicCDecr(number *uint64) bool {
+	for {
+		prev := atomic.LoadUint64(number)
+		if prev == 0 {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(number, prev, prev-1) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gohalt
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Handler defined by typical abstract async func signature that handles a batch of items.
+// Handler is used by `Batcher` as a subject for execution.
+type Handler func(context.Context, []interface{}) error
+
+// Batcher defines abstraction to collect submitted items into batches
+// and to execute `Handler` once per batch.
+// Batcher is designed to simplify work with throttlers
+// by managing `Acquire`/`Release` loop once per batch instead of once per item.
+type Batcher interface {
+	// Submit adds single provided item to the current batch.
+	Submit(item interface{})
+	// Result flushes the current batch, waits for all batches to be handled
+	// and returns possible execution error back.
+	Result() error
+}
+
+type rbatch struct {
+	thr     Throttler
+	ctx     context.Context
+	cancel  context.CancelFunc
+	size    uint64
+	linger  time.Duration
+	handler Handler
+	batch   []interface{}
+	gen     uint64
+	timer   *time.Timer
+	wg      sync.WaitGroup
+	lock    sync.Mutex
+	rep     *reporter
+}
+
+// NewRunnerBatch creates asynchronous batching runner instance
+// that collects submitted items into batches of size defined by the specified size
+// or into smaller batches once the specified linger duration has passed since the batch first item,
+// and then runs the provided handler for each batch simultaneously
+// with regard to the provided context and throttler.
+// Throttler is acquired and released only once per batch with batch size as call cost,
+// see `WithCost` for the list of throttlers that take call cost into account.
+// Note that such throttlers reject any batch which cost exceeds their threshold,
+// so the specified size needs to be less or equal to the throttler threshold,
+// otherwise each full batch is always rejected.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error is returned from result.
+// Use `WithPolicy` to specify errors handling policy.
+func NewRunnerBatch(
+	ctx context.Context,
+	thr Throttler,
+	size uint64,
+	linger time.Duration,
+	handler Handler,
+) Batcher {
+	if size == 0 {
+		size = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &rbatch{
+		thr:     thr,
+		ctx:     ctx,
+		cancel:  cancel,
+		size:    size,
+		linger:  linger,
+		handler: handler,
+		rep:     newReporter(ctx, cancel, "batch"),
+	}
+}
+
+func (r *rbatch) Submit(item interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.batch = append(r.batch, item)
+	if uint64(len(r.batch)) >= r.size {
+		r.flush()
+		return
+	}
+	// start linger timer on the first batch item
+	if len(r.batch) == 1 && r.linger > 0 {
+		gen := r.gen
+		r.timer = time.AfterFunc(r.linger, func() {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			// batch could be already flushed by size
+			if gen == r.gen {
+				r.flush()
+			}
+		})
+	}
+}
+
+func (r *rbatch) Result() error {
+	r.lock.Lock()
+	r.flush()
+	r.lock.Unlock()
+	r.wg.Wait()
+	r.cancel()
+	return r.rep.result()
+}
+
+func (r *rbatch) flush() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if len(r.batch) == 0 {
+		return
+	}
+	batch := r.batch
+	r.batch = nil
+	r.gen++
+	r.wg.Add(1)
+	report := r.rep.next()
+	go func() {
+		defer r.wg.Done()
+		ctx := WithCost(r.ctx, uint64(len(batch)))
+		execute(ctx, r.thr, func(ctx context.Context) error {
+			return r.handler(ctx, batch)
+		}, report)
+	}()
+}
@@ -0,0 +1,87 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatches(t *testing.T) {
+	testerr := errors.New("test")
+	table := map[string]struct {
+		thr     Throttler
+		size    uint64
+		linger  time.Duration
+		items   int
+		wait    time.Duration
+		batches []int
+		herr    error
+		err     error
+	}{
+		"Batch runner should collect batches by size": {
+			thr:     NewThrottlerEcho(nil),
+			size:    2,
+			items:   5,
+			batches: []int{1, 2, 2},
+		},
+		"Batch runner should collect batches by linger": {
+			thr:     NewThrottlerEcho(nil),
+			size:    10,
+			linger:  ms1_0,
+			items:   3,
+			wait:    ms10_0,
+			batches: []int{3},
+		},
+		"Batch runner should acquire throttler with batch size as cost": {
+			thr:     NewThrottlerAfter(3),
+			size:    2,
+			items:   4,
+			batches: []int{2},
+			err:     fmt.Errorf("throttler error has happened %w", errors.New("throttler has exceed threshold")),
+		},
+		"Batch runner should reject batches with cost above throttler threshold": {
+			thr:   NewThrottlerRunning(5),
+			size:  10,
+			items: 10,
+			err:   fmt.Errorf("throttler error has happened %w", errors.New("throttler has exceed running threshold")),
+		},
+		"Batch runner should return handler error": {
+			thr:     NewThrottlerEcho(nil),
+			size:    3,
+			items:   3,
+			batches: []int{3},
+			herr:    testerr,
+			err:     fmt.Errorf("runnable error has happened %w", testerr),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			var batches []int
+			var lock sync.Mutex
+			r := NewRunnerBatch(context.Background(), tcase.thr, tcase.size, tcase.linger, func(_ context.Context, batch []interface{}) error {
+				lock.Lock()
+				defer lock.Unlock()
+				batches = append(batches, len(batch))
+				return tcase.herr
+			})
+			for i := 0; i < tcase.items; i++ {
+				r.Submit(i)
+			}
+			if tcase.wait > 0 {
+				time.Sleep(tcase.wait)
+				lock.Lock()
+				assert.Equal(t, tcase.batches, batches)
+				lock.Unlock()
+			}
+			assert.Equal(t, tcase.err, r.Result())
+			sort.Ints(batches)
+			assert.Equal(t, tcase.batches, batches)
+		})
+	}
+}
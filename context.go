@@ -2,6 +2,7 @@ package gohalt
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -13,6 +14,11 @@ const (
 	ghctxmessage   ghctxid = "gohalt_context_message"
 	ghctxtimestamp ghctxid = "gohalt_context_timestamp"
 	ghctxmarshaler ghctxid = "gohalt_context_marshaler"
+	ghctxticket    ghctxid = "gohalt_context_ticket"
+	ghctxpolicy    ghctxid = "gohalt_context_policy"
+	ghctxfallback  ghctxid = "gohalt_context_fallback"
+	ghctxcost      ghctxid = "gohalt_context_cost"
+	ghctxcallable  ghctxid = "gohalt_context_callable"
 )
 
 // WithTimestamp adds the provided timestamp to the provided context
@@ -34,13 +40,13 @@ func ctxTimestamp(ctx context.Context) time.Time {
 // WithPriority adds the provided priority to the provided context
 // to differ `Acquire` priority levels.
 // Resulted context is used by: `priority` throtttler.
-func WithPriority(ctx context.Context, priority uint8) context.Context {
+func WithPriority(ctx context.Context, priority uint64) context.Context {
 	return context.WithValue(ctx, ghctxpriority, priority)
 }
 
-func ctxPriority(ctx context.Context, limit uint8) uint8 {
+func ctxPriority(ctx context.Context, limit uint64) uint64 {
 	if val := ctx.Value(ghctxpriority); val != nil {
-		if priority, ok := val.(uint8); ok && priority > 0 && priority <= limit {
+		if priority, ok := val.(uint64); ok && priority > 0 && priority <= limit {
 			return priority
 		}
 	}
@@ -90,6 +96,79 @@ func ctxMarshaler(ctx context.Context) Marshaler {
 	return DefaultMarshaler
 }
 
+// WithPolicy adds the provided errors handling policy to the provided context
+// to define how runner handles `Runnable` errors, `PolicyFailFast` by default.
+// Resulted context is used by: all runners, batches, pipelines and pipes only on creation,
+// the same value in call context provided to `RunContext` is ignored.
+func WithPolicy(ctx context.Context, policy Policy) context.Context {
+	return context.WithValue(ctx, ghctxpolicy, policy)
+}
+
+func ctxPolicy(ctx context.Context) Policy {
+	if val, ok := ctx.Value(ghctxpolicy).(Policy); ok {
+		return val
+	}
+	return PolicyFailFast
+}
+
+// WithFallback adds the provided fallback to the provided context
+// to run it instead of `Runnable` rejected by throttler.
+// Resulted context is used by: `sync`, `async`, `pool` and `future` runners.
+func WithFallback(ctx context.Context, fallback Fallback) context.Context {
+	return context.WithValue(ctx, ghctxfallback, fallback)
+}
+
+func ctxFallback(ctx context.Context) Fallback {
+	if val, ok := ctx.Value(ghctxfallback).(Fallback); ok {
+		return val
+	}
+	return nil
+}
+
+// WithFallbackCallable adds the provided value fallback to the provided context
+// to run it instead of `Callable` rejected by throttler
+// and to return its value back as degraded result.
+// Value fallback takes precedence over `WithFallback` fallback.
+// Resulted context is used by: `future` runner.
+func WithFallbackCallable(ctx context.Context, fallback FallbackCallable) context.Context {
+	return context.WithValue(ctx, ghctxcallable, fallback)
+}
+
+func ctxFallbackCallable(ctx context.Context) FallbackCallable {
+	if val, ok := ctx.Value(ghctxcallable).(FallbackCallable); ok {
+		return val
+	}
+	return nil
+}
+
+// WithCost adds the provided cost to the provided context
+// to define how much throttling quota single call takes, 1 by default.
+// Resulted context is used by: `before`, `after`, `running`, `timed` and `adaptive` throttlers.
+func WithCost(ctx context.Context, cost uint64) context.Context {
+	return context.WithValue(ctx, ghctxcost, cost)
+}
+
+func ctxCost(ctx context.Context) uint64 {
+	if val, ok := ctx.Value(ghctxcost).(uint64); ok {
+		return val
+	}
+	return 1
+}
+
+func withTicket(ctx context.Context, t *ticket) context.Context {
+	if t == nil && ctxTicket(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ghctxticket, t)
+}
+
+func ctxTicket(ctx context.Context) *ticket {
+	if t, ok := ctx.Value(ghctxticket).(*ticket); ok {
+		return t
+	}
+	return nil
+}
+
 // WithParams facade call that respectively calls:
 // - `WithTimestamp`
 // - `WithPriority`
@@ -99,7 +178,7 @@ func ctxMarshaler(ctx context.Context) Marshaler {
 func WithParams(
 	ctx context.Context,
 	ts time.Time,
-	priority uint8,
+	priority uint64,
 	key string,
 	message interface{},
 	marshaler Marshaler,
@@ -112,6 +191,91 @@ func WithParams(
 	return ctx
 }
 
+type ctxmerge struct {
+	context.Context
+	values context.Context
+}
+
+// withMerge returns context that carries values of the provided call context
+// on top of the provided base context values and that is done
+// either if base context or call context is done.
+// If call context is done first, merged context error is the call context error.
+func withMerge(base context.Context, call context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctxmerge{Context: base, values: call})
+	mctx := &ctxmerged{Context: ctx, cancel: cancel}
+	if call.Err() != nil {
+		mctx.stop(call.Err())
+		return mctx, cancel
+	}
+	if call.Done() != nil {
+		go func() {
+			select {
+			case <-call.Done():
+				mctx.stop(call.Err())
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return mctx, cancel
+}
+
+func (ctx ctxmerge) Deadline() (time.Time, bool) {
+	deadline, ok := ctx.Context.Deadline()
+	if cdeadline, cok := ctx.values.Deadline(); cok && (!ok || cdeadline.Before(deadline)) {
+		return cdeadline, true
+	}
+	return deadline, ok
+}
+
+func (ctx ctxmerge) Value(key interface{}) interface{} {
+	if val := ctx.values.Value(key); val != nil {
+		return val
+	}
+	return ctx.Context.Value(key)
+}
+
+type ctxmerged struct {
+	context.Context
+	cancel context.CancelFunc
+	err    error
+	lock   sync.Mutex
+}
+
+func (ctx *ctxmerged) Err() error {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if ctx.err != nil {
+		return ctx.err
+	}
+	return ctx.Context.Err()
+}
+
+// stop cancels merged context with the provided call context error
+// unless merged context has been already done.
+func (ctx *ctxmerged) stop(err error) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if ctx.Context.Err() == nil {
+		ctx.err = err
+	}
+	ctx.cancel()
+}
+
+type ctxvalues struct {
+	context.Context
+	values context.Context
+}
+
+// withValues returns context that carries only values of the provided context
+// and that is never done, so it could outlive the provided context.
+func withValues(ctx context.Context) context.Context {
+	return ctxvalues{Context: context.Background(), values: ctx}
+}
+
+func (ctx ctxvalues) Value(key interface{}) interface{} {
+	return ctx.values.Value(key)
+}
+
 type ctxthr struct {
 	context.Context
 	thr  Throttler
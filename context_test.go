@@ -55,3 +55,19 @@ func TestContext(t *testing.T) {
 		})
 	}
 }
+
+func TestContextMerge(t *testing.T) {
+	call, ccancel := context.WithTimeout(context.Background(), ms1_0)
+	defer ccancel()
+	ctx, cancel := withMerge(context.Background(), call)
+	defer cancel()
+	<-ctx.Done()
+	// call deadline is kept as merged context error
+	assert.True(t, errors.Is(ctx.Err(), context.DeadlineExceeded))
+	base, bcancel := context.WithCancel(context.Background())
+	ctx, cancel = withMerge(base, context.Background())
+	defer cancel()
+	bcancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
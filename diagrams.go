@@ -0,0 +1,264 @@
+package gohalt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Diagram defines throttlers tree diagram format.
+type Diagram uint8
+
+const (
+	// DiagramDOT defines Graphviz DOT diagram format.
+	DiagramDOT Diagram = iota
+	// DiagramMermaid defines Mermaid flowchart diagram format.
+	DiagramMermaid
+)
+
+type dnode struct {
+	name   string
+	params []string
+}
+
+type dedge struct {
+	from  int
+	to    int
+	label string
+}
+
+type dgraph struct {
+	nodes []dnode
+	edges []dedge
+	meta  bool
+}
+
+// WriteDiagram walks the provided composed throttlers tree
+// and writes it to the provided writer in the specified diagram format
+// with the throttler parameters on each node.
+// If meta is set then live meta of each `Tunable` throttler is added to its node as well.
+func WriteDiagram(w io.Writer, thr Throttler, diagram Diagram, meta bool) error {
+	g := &dgraph{meta: meta}
+	g.walk(thr)
+	var lines []string
+	switch diagram {
+	case DiagramDOT:
+		lines = g.dot()
+	case DiagramMermaid:
+		lines = g.mermaid()
+	default:
+		return fmt.Errorf("diagram hasn't found any format %d", diagram)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func (g *dgraph) walk(thr Throttler) {
+	name, params, children, labels := describe(thr)
+	if tunable, ok := thr.(Tunable); ok && g.meta {
+		meta := tunable.Meta()
+		keys := make([]string, 0, len(meta))
+	next:
+		for key := range meta {
+			// skip meta that is already shown as param
+			for _, param := range params {
+				if strings.HasPrefix(param, key+"=") {
+					continue next
+				}
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			params = append(params, fmt.Sprintf("%s: %v", key, meta[key]))
+		}
+	}
+	id := len(g.nodes)
+	g.nodes = append(g.nodes, dnode{name: name, params: params})
+	for i, child := range children {
+		var label string
+		if i < len(labels) {
+			label = labels[i]
+		}
+		g.edges = append(g.edges, dedge{from: id, to: len(g.nodes), label: label})
+		g.walk(child)
+	}
+}
+
+func (g *dgraph) dot() []string {
+	quote := func(str string) string {
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(str)
+	}
+	lines := []string{"digraph gohalt {", "\tnode [shape=box];"}
+	for id, node := range g.nodes {
+		label := quote(node.name)
+		for _, param := range node.params {
+			label += `\n` + quote(param)
+		}
+		lines = append(lines, fmt.Sprintf("\tn%d [label=\"%s\"];", id, label))
+	}
+	for _, edge := range g.edges {
+		if edge.label != "" {
+			lines = append(lines, fmt.Sprintf("\tn%d -> n%d [label=\"%s\"];", edge.from, edge.to, quote(edge.label)))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("\tn%d -> n%d;", edge.from, edge.to))
+	}
+	return append(lines, "}")
+}
+
+func (g *dgraph) mermaid() []string {
+	quote := func(str string) string {
+		return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "<", "#lt;", ">", "#gt;").Replace(str)
+	}
+	lines := []string{"graph TD"}
+	for id, node := range g.nodes {
+		label := quote(node.name)
+		for _, param := range node.params {
+			label += "<br/>" + quote(param)
+		}
+		lines = append(lines, fmt.Sprintf("\tn%d[\"%s\"]", id, label))
+	}
+	for _, edge := range g.edges {
+		if edge.label != "" {
+			lines = append(lines, fmt.Sprintf("\tn%d -->|\"%s\"| n%d", edge.from, quote(edge.label), edge.to))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("\tn%d --> n%d", edge.from, edge.to))
+	}
+	return lines
+}
+
+// describe returns throttler diagram name, its parameters,
+// its child throttlers and optional labels for child edges.
+func describe(thr Throttler) (name string, params []string, children []Throttler, labels []string) {
+	param := func(key string, val interface{}) string {
+		return fmt.Sprintf("%s=%v", key, val)
+	}
+	switch thr := thr.(type) {
+	case techo:
+		return "echo", []string{param("err", thr.err)}, nil, nil
+	case twait:
+		return "wait", []string{param("duration", thr.duration)}, nil, nil
+	case *tsquare:
+		return "square", []string{
+			param("initial", thr.initial),
+			param("limit", thr.limit),
+			param("reset", thr.reset),
+		}, nil, nil
+	case *tjitter:
+		return "jitter", []string{
+			param("initial", thr.initial),
+			param("limit", thr.limit),
+			param("reset", thr.reset),
+			param("jitter", 1.0-thr.jitter),
+		}, nil, nil
+	case tcontext:
+		return "context", nil, nil, nil
+	case tpanic:
+		return "panic", nil, nil, nil
+	case *teach:
+		return "each", []string{param("threshold", atomicGet(&thr.threshold))}, nil, nil
+	case *tbefore:
+		return "before", []string{param("threshold", atomicGet(&thr.threshold))}, nil, nil
+	case *tafter:
+		return "after", []string{param("threshold", atomicGet(&thr.threshold))}, nil, nil
+	case *tchance:
+		return "chance", []string{param("threshold", thr.Meta()["threshold"])}, nil, nil
+	case *trunning:
+		return "running", []string{param("threshold", atomicGet(&thr.threshold))}, nil, nil
+	case *tbuffered:
+		return "buffered", []string{
+			param("threshold", thr.threshold),
+			param("discipline", thr.discipline),
+		}, nil, nil
+	case *tpriority:
+		return "priority", []string{
+			param("threshold", thr.threshold),
+			param("levels", thr.levels),
+			param("discipline", thr.discipline),
+		}, nil, nil
+	case *ttimed:
+		return "timed", []string{
+			param("threshold", atomicGet(&thr.threshold)),
+			param("interval", thr.interval),
+			param("quantum", thr.quantum),
+		}, nil, nil
+	case *tlatency:
+		return "latency", []string{param("threshold", thr.Meta()["threshold"])}, nil, nil
+	case *tpercentile:
+		return "percentile", []string{
+			param("threshold", thr.Meta()["threshold"]),
+			param("capacity", thr.latencies.cap),
+			param("percentile", thr.Meta()["percentile"]),
+		}, nil, nil
+	case tdeadline:
+		return "deadline", []string{
+			param("capacity", thr.latencies.cap),
+			param("percentile", thr.Meta()["percentile"]),
+		}, nil, nil
+	case tmonitor:
+		return "monitor", []string{param("threshold", fmt.Sprintf("%+v", thr.threshold))}, nil, nil
+	case *tswitch:
+		return "switch", nil, nil, nil
+	case *tlease:
+		meta := thr.Meta()
+		return "lease", []string{
+			param("threshold", meta["threshold"]),
+			param("ttl", meta["ttl"]),
+		}, nil, nil
+	case *tdrain:
+		return "drain", []string{
+			param("drained", atomicGet(&thr.drained) > 0),
+			param("inflight", thr.InFlight()),
+		}, nil, nil
+	case tmetric:
+		return "metric", nil, nil, nil
+	case tenqueue:
+		return "enqueue", nil, nil, nil
+	case *tadaptive:
+		return "adaptive", []string{
+			param("threshold", atomicGet(&thr.threshold)),
+			param("interval", thr.interval),
+			param("quantum", thr.quantum),
+			param("step", thr.step),
+		}, []Throttler{thr.thr}, nil
+	case tpattern:
+		for _, pattern := range thr {
+			children = append(children, pattern.Throttler)
+			labels = append(labels, pattern.Pattern.String())
+		}
+		return "pattern", nil, children, labels
+	case *tring:
+		return "ring", nil, thr.thrs, nil
+	case tall:
+		return "all", nil, thr, nil
+	case tany:
+		return "any", nil, thr, nil
+	case tnot:
+		return "not", nil, []Throttler{thr.thr}, nil
+	case tsuppress:
+		return "suppress", nil, []Throttler{thr.thr}, nil
+	case tretry:
+		return "retry", []string{param("retries", thr.retries)}, []Throttler{thr.thr}, nil
+	case tshaping:
+		return "shaping", []string{
+			param("initial", thr.initial),
+			param("limit", thr.limit),
+		}, []Throttler{thr.thr}, nil
+	case tcache:
+		return "cache", []string{param("cache", thr.cache)}, []Throttler{thr.thr}, nil
+	case *tshadow:
+		return "shadow", nil, []Throttler{thr.thr, thr.candidate}, []string{"enforced", "candidate"}
+	case *tcanary:
+		return "canary", []string{param("percentage", thr.Meta()["percentage"])}, thr.thrs[:], []string{"current", "candidate"}
+	case *tadmin:
+		return "admin", []string{
+			param("name", thr.name),
+			param("mode", modes[atomicGet(&thr.mode)]),
+		}, []Throttler{thr.thr}, nil
+	default:
+		return fmt.Sprintf("%T", thr), nil, nil, nil
+	}
+}
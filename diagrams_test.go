@@ -0,0 +1,79 @@
+package gohalt
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagrams(t *testing.T) {
+	thr := NewThrottlerAll(
+		NewThrottlerPattern(
+			Pattern{
+				Pattern:   regexp.MustCompile(`192\..*`),
+				Throttler: NewThrottlerRetry(NewThrottlerAfter(3), 2),
+			},
+		),
+		NewThrottlerNot(NewThrottlerCache(NewThrottlerEach(3), time.Second)),
+	)
+	table := map[string]struct {
+		thr     Throttler
+		diagram Diagram
+		meta    bool
+		out     string
+		err     error
+	}{
+		"Diagram dot should render throttlers tree": {
+			thr:     thr,
+			diagram: DiagramDOT,
+			out: strings.Join([]string{
+				"digraph gohalt {",
+				"\tnode [shape=box];",
+				"\tn0 [label=\"all\"];",
+				"\tn1 [label=\"pattern\"];",
+				"\tn2 [label=\"retry\\nretries=2\"];",
+				"\tn3 [label=\"after\\nthreshold=3\"];",
+				"\tn4 [label=\"not\"];",
+				"\tn5 [label=\"cache\\ncache=1s\"];",
+				"\tn6 [label=\"each\\nthreshold=3\"];",
+				"\tn0 -> n1;",
+				"\tn1 -> n2 [label=\"192\\\\..*\"];",
+				"\tn2 -> n3;",
+				"\tn0 -> n4;",
+				"\tn4 -> n5;",
+				"\tn5 -> n6;",
+				"}",
+				"",
+			}, "\n"),
+		},
+		"Diagram mermaid should render throttlers tree with meta": {
+			thr:     NewThrottlerSuppress(NewThrottlerRunning(2)),
+			diagram: DiagramMermaid,
+			meta:    true,
+			out: strings.Join([]string{
+				"graph TD",
+				"\tn0[\"suppress\"]",
+				"\tn1[\"running<br/>threshold=2<br/>running: 0\"]",
+				"\tn0 --> n1",
+				"",
+			}, "\n"),
+		},
+		"Diagram should fail on unknown format": {
+			thr:     NewThrottlerEcho(nil),
+			diagram: Diagram(10),
+			err:     errors.New("diagram hasn't found any format 10"),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			var out strings.Builder
+			err := WriteDiagram(&out, tcase.thr, tcase.diagram, tcase.meta)
+			assert.Equal(t, tcase.err, err)
+			assert.Equal(t, tcase.out, out.String())
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// ErrDrained defines distinct error returned by drain throttler
+// for each call acquired after the drain has been started.
+var ErrDrained = errors.New("throttler has been drained")
+
+// Drainer defines graceful drain controller throttler
+// that rejects all new calls once the drain is started
+// and keeps track of calls that are still in flight.
+type Drainer interface {
+	Throttler
+	// Drain starts the drain, all subsequent acquires are rejected with `ErrDrained`.
+	Drain()
+	// InFlight returns the number of acquired but not yet released calls.
+	InFlight() uint64
+	// Wait waits until the drain is started and all in flight calls are released
+	// or returns error with the number of calls in flight if the provided context is done first.
+	Wait(context.Context) error
+}
+
+type tdrain struct {
+	running  *trunning
+	drained  uint64
+	rejected uint64
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewThrottlerDrain creates new drain throttler instance that
+// passes all calls until the drain is started with `Drain`
+// and then throttles each call with distinct `ErrDrained` error.
+// Drain throttler keeps track of running calls same way as `running` throttler does,
+// so `InFlight` and `Wait` could be used to wait for all in flight calls on shutdown.
+// Calls rejected with `ErrDrained` are not counted as in flight,
+// their releases are balanced and never release other calls in flight.
+// Use `DrainOnSignal` to start the drain on os signal like `SIGTERM`.
+func NewThrottlerDrain() Drainer {
+	return &tdrain{
+		running: &trunning{threshold: math.MaxUint64},
+		done:    make(chan struct{}),
+	}
+}
+
+func (thr *tdrain) Acquire(ctx context.Context) error {
+	if err := thr.acquire(ctx); err != nil {
+		// remember rejected call so its release is balanced
+		atomicIncr(&thr.rejected)
+		return err
+	}
+	return nil
+}
+
+func (thr *tdrain) Release(ctx context.Context) error {
+	if atomicCDecr(&thr.rejected) {
+		return nil
+	}
+	return thr.release(ctx)
+}
+
+func (thr *tdrain) acquire(ctx context.Context) error {
+	// count the call first so drain can't miss it in flight
+	if err := thr.running.Acquire(ctx); err != nil {
+		return err
+	}
+	if atomicGet(&thr.drained) > 0 {
+		_ = thr.running.Release(ctx)
+		thr.idle()
+		return ErrDrained
+	}
+	return nil
+}
+
+func (thr *tdrain) release(ctx context.Context) error {
+	if err := thr.running.Release(ctx); err != nil {
+		return err
+	}
+	thr.idle()
+	return nil
+}
+
+func (thr *tdrain) Drain() {
+	atomicSet(&thr.drained, 1)
+	thr.idle()
+}
+
+func (thr *tdrain) InFlight() uint64 {
+	return atomicGet(&thr.running.running)
+}
+
+func (thr *tdrain) Wait(ctx context.Context) error {
+	select {
+	case <-thr.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("throttler hasn't drained %d calls in flight %w", thr.InFlight(), ctx.Err())
+	}
+}
+
+func (thr *tdrain) idle() {
+	if atomicGet(&thr.drained) > 0 && thr.InFlight() == 0 {
+		thr.once.Do(func() {
+			close(thr.done)
+		})
+	}
+}
+
+// DrainOnSignal starts the drain of the provided drainer
+// once any of the provided os signals is received.
+// Returned stop func stops watching the signals.
+func DrainOnSignal(drn Drainer, signals ...os.Signal) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, signals...)
+	go func() {
+		select {
+		case sig := <-sigs:
+			log("drain has been started by signal %v", sig)
+			drn.Drain()
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigs)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package gohalt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	thr := NewThrottlerDrain()
+	ctx := context.Background()
+	assert.NoError(t, thr.Acquire(ctx))
+	assert.NoError(t, thr.Acquire(ctx))
+	assert.Equal(t, uint64(2), thr.InFlight())
+	thr.Drain()
+	assert.Equal(t, ErrDrained, thr.Acquire(ctx))
+	assert.Equal(t, ErrDrained, thr.Acquire(ctx))
+	// rejected calls are not counted as in flight
+	assert.Equal(t, uint64(2), thr.InFlight())
+	// and their releases never release other calls
+	assert.NoError(t, thr.Release(ctx))
+	assert.NoError(t, thr.Release(ctx))
+	assert.Equal(t, uint64(2), thr.InFlight())
+	tkt, err := AcquireTicket(ctx, thr)
+	assert.Equal(t, ErrDrained, err)
+	assert.NoError(t, tkt.Release(ctx))
+	assert.Equal(t, uint64(2), thr.InFlight())
+	wctx, cancel := context.WithTimeout(ctx, ms1_0)
+	defer cancel()
+	assert.Equal(
+		t,
+		fmt.Errorf("throttler hasn't drained %d calls in flight %w", 2, context.DeadlineExceeded),
+		thr.Wait(wctx),
+	)
+	go func() {
+		time.Sleep(ms5_0)
+		_ = thr.Release(ctx)
+		_ = thr.Release(ctx)
+	}()
+	assert.NoError(t, thr.Wait(ctx))
+	assert.Equal(t, uint64(0), thr.InFlight())
+}
+
+func TestDrainRace(t *testing.T) {
+	thr := NewThrottlerDrain()
+	ctx := context.Background()
+	waited := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := thr.Acquire(ctx); err == nil {
+				// admitted calls are always waited for
+				select {
+				case <-waited:
+					t.Error("call is admitted after drain wait has returned")
+				default:
+				}
+			}
+			_ = thr.Release(ctx)
+		}()
+	}
+	thr.Drain()
+	assert.NoError(t, thr.Wait(ctx))
+	close(waited)
+	wg.Wait()
+	assert.Equal(t, uint64(0), thr.InFlight())
+}
+
+func TestDrainOnSignal(t *testing.T) {
+	thr := NewThrottlerDrain()
+	stop := DrainOnSignal(thr, os.Interrupt)
+	defer stop()
+	proc, err := os.FindProcess(os.Getpid())
+	assert.NoError(t, err)
+	if err := proc.Signal(os.Interrupt); err != nil {
+		t.Skipf("signal isn't supported %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ms30_0)
+	defer cancel()
+	assert.NoError(t, thr.Wait(ctx))
+	assert.Equal(t, ErrDrained, thr.Acquire(context.Background()))
+}
@@ -0,0 +1,132 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicError defines typed error for recovered panic
+// which keeps recovered panic value and stack trace.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (err PanicError) Error() string {
+	return fmt.Sprintf("panic has happened %v", err.Value)
+}
+
+// TuneError defines typed `Tunable` tune error which tells apart
+// params that aren't tunable from invalid values of tunable params.
+type TuneError struct {
+	Param   string
+	Value   float64
+	Invalid bool
+}
+
+func (err TuneError) Error() string {
+	if err.Invalid {
+		return fmt.Sprintf("throttler tunable param %q value %v is invalid", err.Param, err.Value)
+	}
+	return fmt.Sprintf("throttler hasn't found any tunable param %q", err.Param)
+}
+
+// Reason defines kind of throttle reason.
+type Reason uint8
+
+const (
+	// ReasonRejected defines call rejected by throttler quota or state.
+	ReasonRejected Reason = iota
+	// ReasonDrained defines call rejected by drained `drain` throttler.
+	ReasonDrained
+	// ReasonCanceled defines call rejected by throttler because of context error.
+	ReasonCanceled
+	// ReasonPanicked defines call rejected by panicked throttler.
+	ReasonPanicked
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonDrained:
+		return "drained"
+	case ReasonCanceled:
+		return "canceled"
+	case ReasonPanicked:
+		return "panicked"
+	default:
+		return "rejected"
+	}
+}
+
+// ThrottleError defines typed throttle reason
+// which keeps throttler error that rejected `Runnable` execution,
+// kind of the reason and the throttler that rejected the call.
+// Throttler is the innermost throttler that returned error
+// or the root throttler if it's unknown.
+type ThrottleError struct {
+	Err       error
+	Reason    Reason
+	Throttler Throttler
+}
+
+func newThrottleError(err error, thr Throttler) ThrottleError {
+	reason := ReasonRejected
+	var perr PanicError
+	switch {
+	case errors.As(err, &perr):
+		reason = ReasonPanicked
+	case errors.Is(err, ErrDrained):
+		reason = ReasonDrained
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		reason = ReasonCanceled
+	}
+	return ThrottleError{Err: err, Reason: reason, Throttler: thr}
+}
+
+func (err ThrottleError) Error() string {
+	return fmt.Sprintf("throttler has rejected call %v", err.Err)
+}
+
+func (err ThrottleError) Unwrap() error {
+	return err.Err
+}
+
+// RunError defines typed error which keeps index of `Runnable`
+// in runner submission order that caused the error.
+type RunError struct {
+	Index uint64
+	Err   error
+}
+
+func (err RunError) Error() string {
+	return fmt.Sprintf("runnable %d %v", err.Index, err.Err)
+}
+
+func (err RunError) Unwrap() error {
+	return err.Err
+}
+
+// MultiError defines typed error which aggregates
+// errors of multiple `Runnable` ordered by their indexes.
+type MultiError []RunError
+
+func (err MultiError) Error() string {
+	errs := make([]string, 0, len(err))
+	for _, rerr := range err {
+		errs = append(errs, rerr.Error())
+	}
+	return fmt.Sprintf("multiple errors have happened [%s]", strings.Join(errs, "; "))
+}
+
+// catch runs the provided func and converts its possible panic into `PanicError`.
+func catch(run func() error) (err error) {
+	defer func() {
+		if msg := recover(); msg != nil {
+			err = PanicError{Value: msg, Stack: debug.Stack()}
+		}
+	}()
+	return run()
+}
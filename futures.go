@@ -0,0 +1,187 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Callable defined by typical abstract async func signature that also returns a value.
+// Callable is used by `FutureRunner` as a subject for execution.
+type Callable func(context.Context) (interface{}, error)
+
+// FallbackCallable defines value fallback that is executed by future runner
+// instead of `Callable` rejected by throttler, its value is returned back as degraded result.
+// Use `WithFallbackCallable` to specify value fallback for runner or for single call.
+type FallbackCallable func(context.Context, ThrottleError) (interface{}, error)
+
+// Status defines `Future` execution outcome status.
+type Status uint64
+
+const (
+	// StatusPending defines not yet finished execution.
+	StatusPending Status = iota
+	// StatusDone defines successfully finished execution.
+	StatusDone
+	// StatusThrottled defines execution that was throttled before `Callable` started.
+	StatusThrottled
+	// StatusCanceled defines execution that was canceled by context.
+	StatusCanceled
+	// StatusFailed defines execution that finished with error.
+	StatusFailed
+	// StatusFallback defines execution that was throttled
+	// and successfully replaced by the fallback result.
+	StatusFallback
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusDone:
+		return "done"
+	case StatusThrottled:
+		return "throttled"
+	case StatusCanceled:
+		return "canceled"
+	case StatusFailed:
+		return "failed"
+	case StatusFallback:
+		return "fallback"
+	default:
+		return "pending"
+	}
+}
+
+// Future defines handle of single submitted `Callable` execution.
+type Future interface {
+	// Wait waits until execution is finished and returns `Callable` value and error back
+	// or returns context error if the provided context is done first.
+	Wait(context.Context) (interface{}, error)
+	// Status returns execution outcome status.
+	Status() Status
+	// Timing returns `Callable` start timestamp and running duration
+	// or zero values if `Callable` hasn't finished yet or hasn't been started at all.
+	Timing() (time.Time, time.Duration)
+}
+
+// FutureRunner defines abstraction to execute a set of `Callable`
+// and return each execution result back as `Future`.
+// FutureRunner is designed to simplify work with throttlers
+// by managing `Acquire`/`Release` loop.
+type FutureRunner interface {
+	// Submit executes single provided `Callable` instance
+	// and returns its execution handle.
+	Submit(Callable) Future
+	// SubmitContext executes single provided `Callable` instance
+	// with the provided call context values merged with the runner context
+	// and returns its execution handle.
+	SubmitContext(context.Context, Callable) Future
+}
+
+type future struct {
+	done   chan struct{}
+	status uint64
+	value  interface{}
+	err    error
+	start  time.Time
+	dur    time.Duration
+}
+
+func (f *future) Wait(ctx context.Context) (interface{}, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *future) Status() Status {
+	return Status(atomicGet(&f.status))
+}
+
+func (f *future) Timing() (time.Time, time.Duration) {
+	select {
+	case <-f.done:
+		return f.start, f.dur
+	default:
+		return time.Time{}, 0
+	}
+}
+
+type rfuture struct {
+	thr Throttler
+	ctx context.Context
+}
+
+// NewRunnerFuture creates asynchronous future runner instance
+// that runs a set of `Callable` simultaneously
+// with regard to the provided context and throttler
+// and returns each execution result back as `Future`.
+// Unlike other runners each execution error affects only its own `Future`.
+func NewRunnerFuture(ctx context.Context, thr Throttler) FutureRunner {
+	return rfuture{thr: thr, ctx: ctx}
+}
+
+func (r rfuture) Submit(call Callable) Future {
+	return r.submit(r.ctx, nil, call)
+}
+
+func (r rfuture) SubmitContext(ctx context.Context, call Callable) Future {
+	ctx, cancel := withMerge(r.ctx, ctx)
+	return r.submit(ctx, cancel, call)
+}
+
+func (r rfuture) submit(ctx context.Context, cancel context.CancelFunc, call Callable) Future {
+	f := &future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		if cancel != nil {
+			defer cancel()
+		}
+		var started, fellback bool
+		// keep track of fallback to differ degraded result from success
+		if fallback := ctxFallbackCallable(ctx); fallback != nil {
+			ctx = WithFallback(ctx, func(ctx context.Context, terr ThrottleError) error {
+				fellback = true
+				var err error
+				f.value, err = fallback(ctx, terr)
+				return err
+			})
+		} else if fallback := ctxFallback(ctx); fallback != nil {
+			ctx = WithFallback(ctx, func(ctx context.Context, terr ThrottleError) error {
+				fellback = true
+				return fallback(ctx, terr)
+			})
+		}
+		execute(ctx, r.thr, func(ctx context.Context) error {
+			started = true
+			f.start = time.Now().UTC()
+			defer func() {
+				f.dur = time.Since(f.start)
+			}()
+			var err error
+			f.value, err = call(ctx)
+			return err
+		}, func(err error) {
+			if f.err == nil {
+				f.err = err
+				log("future runner error happened %v", err)
+			}
+		})
+		var status Status
+		switch {
+		case f.err == nil && fellback:
+			status = StatusFallback
+		case f.err == nil:
+			status = StatusDone
+		case errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded):
+			status = StatusCanceled
+		case started || fellback:
+			status = StatusFailed
+		default:
+			status = StatusThrottled
+		}
+		atomicSet(&f.status, uint64(status))
+	}()
+	return f
+}
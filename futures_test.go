@@ -0,0 +1,151 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFutures(t *testing.T) {
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	testerr := errors.New("test")
+	table := map[string]struct {
+		r      FutureRunner
+		ctx    context.Context
+		call   Callable
+		value  interface{}
+		err    error
+		status Status
+		timed  bool
+	}{
+		"Future runner should return value back on success": {
+			r: NewRunnerFuture(context.Background(), NewThrottlerEcho(nil)),
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			value:  10,
+			status: StatusDone,
+			timed:  true,
+		},
+		"Future runner should return throttled status on throttler error": {
+			r: NewRunnerFuture(context.Background(), NewThrottlerEcho(testerr)),
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			err:    fmt.Errorf("throttler error has happened %w", testerr),
+			status: StatusThrottled,
+		},
+		"Future runner should return fallback status on throttler error with fallback": {
+			r: NewRunnerFuture(WithFallback(context.Background(), func(context.Context, ThrottleError) error {
+				return nil
+			}), NewThrottlerEcho(testerr)),
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			status: StatusFallback,
+		},
+		"Future runner should return fallback value back on throttler error with value fallback": {
+			r: NewRunnerFuture(context.Background(), NewThrottlerEcho(testerr)),
+			ctx: WithFallbackCallable(context.Background(), func(_ context.Context, terr ThrottleError) (interface{}, error) {
+				return terr.Reason.String(), nil
+			}),
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			value:  "rejected",
+			status: StatusFallback,
+		},
+		"Future runner should return failed status on value fallback error": {
+			r: NewRunnerFuture(WithFallbackCallable(context.Background(), func(context.Context, ThrottleError) (interface{}, error) {
+				return nil, testerr
+			}), NewThrottlerEcho(testerr)),
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			err:    fmt.Errorf("fallback error has happened %w", testerr),
+			status: StatusFailed,
+		},
+		"Future runner should return canceled status on canceled context": {
+			r: NewRunnerFuture(cctx, NewThrottlerEcho(nil)),
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			err:    fmt.Errorf("context error has happened %w", context.Canceled),
+			status: StatusCanceled,
+		},
+		"Future runner should return canceled status on canceled call context": {
+			r:   NewRunnerFuture(context.Background(), NewThrottlerEcho(nil)),
+			ctx: cctx,
+			call: func(context.Context) (interface{}, error) {
+				return 10, nil
+			},
+			err:    fmt.Errorf("context error has happened %w", context.Canceled),
+			status: StatusCanceled,
+		},
+		"Future runner should return failed status on runnable error": {
+			r: NewRunnerFuture(context.Background(), NewThrottlerEcho(nil)),
+			call: func(context.Context) (interface{}, error) {
+				return 10, testerr
+			},
+			value:  10,
+			err:    fmt.Errorf("runnable error has happened %w", testerr),
+			status: StatusFailed,
+			timed:  true,
+		},
+		"Future runner should return failed status on runnable panic": {
+			r: NewRunnerFuture(context.Background(), NewThrottlerEcho(nil)),
+			call: func(context.Context) (interface{}, error) {
+				panic("test")
+			},
+			status: StatusFailed,
+			timed:  true,
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			var f Future
+			if tcase.ctx != nil {
+				f = tcase.r.SubmitContext(tcase.ctx, tcase.call)
+			} else {
+				f = tcase.r.Submit(tcase.call)
+			}
+			value, err := f.Wait(context.Background())
+			assert.Equal(t, tcase.value, value)
+			var perr PanicError
+			if errors.As(err, &perr) {
+				assert.Equal(t, "test", perr.Value)
+			} else {
+				assert.Equal(t, tcase.err, err)
+			}
+			assert.Equal(t, tcase.status, f.Status())
+			start, dur := f.Timing()
+			assert.Equal(t, tcase.timed, !start.IsZero())
+			assert.True(t, dur >= 0)
+		})
+	}
+}
+
+func TestFuturesWait(t *testing.T) {
+	r := NewRunnerFuture(context.Background(), NewThrottlerEcho(nil))
+	f := r.Submit(func(context.Context) (interface{}, error) {
+		time.Sleep(ms10_0)
+		return 10, nil
+	})
+	assert.Equal(t, StatusPending, f.Status())
+	ctx, cancel := context.WithTimeout(context.Background(), ms1_0)
+	defer cancel()
+	value, err := f.Wait(ctx)
+	assert.Nil(t, value)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	value, err = f.Wait(context.Background())
+	assert.Equal(t, 10, value)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusDone, f.Status())
+	_, dur := f.Timing()
+	assert.True(t, dur >= ms10_0)
+}
@@ -0,0 +1,241 @@
+package gohalt
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Lease defines running quota slot taken by single `AcquireLease` call
+// that is reclaimed automatically once its ttl expires.
+type Lease interface {
+	Ticket
+	// Renew extends the lease ttl starting from now
+	// or returns error if the lease has been already released or reclaimed.
+	Renew(context.Context) error
+}
+
+// Leaser defines lease based concurrency throttler
+// that also allows to acquire renewable leases.
+type Leaser interface {
+	Throttler
+	// AcquireLease takes a lease same way as `Acquire` does and returns error if running quota is drained,
+	// but also returns the lease that needs to be released or renewed by its holder.
+	// Lease is returned even if running quota is drained, but such lease doesn't hold any running quota.
+	AcquireLease(context.Context) (Lease, error)
+}
+
+type lease struct {
+	thr     *tlease
+	expires time.Time
+	// index in leases heap, negative once lease is gone
+	index int
+	// position in plain leases list, nil for leases taken by `AcquireLease`
+	plain *list.Element
+}
+
+func (l *lease) Release(context.Context) error {
+	l.thr.lock.Lock()
+	defer l.thr.lock.Unlock()
+	// released and reclaimed leases are ignored
+	l.thr.remove(l)
+	return nil
+}
+
+func (l *lease) Renew(context.Context) error {
+	l.thr.lock.Lock()
+	defer l.thr.lock.Unlock()
+	l.thr.reclaim()
+	if l.index < 0 {
+		return errors.New("throttler hasn't found any lease")
+	}
+	l.expires = time.Now().UTC().Add(l.thr.ttl)
+	heap.Fix(&l.thr.leases, l.index)
+	return nil
+}
+
+// lheap defines leases min heap ordered by lease expiry.
+type lheap []*lease
+
+func (h lheap) Len() int {
+	return len(h)
+}
+
+func (h lheap) Less(i, j int) bool {
+	return h[i].expires.Before(h[j].expires)
+}
+
+func (h lheap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lheap) Push(x interface{}) {
+	l := x.(*lease)
+	l.index = len(*h)
+	*h = append(*h, l)
+}
+
+func (h *lheap) Pop() interface{} {
+	old := *h
+	l := old[len(old)-1]
+	old[len(old)-1] = nil
+	l.index = -1
+	*h = old[:len(old)-1]
+	return l
+}
+
+type tlease struct {
+	leases    lheap
+	plain     *list.List
+	threshold uint64
+	ttl       time.Duration
+	reclaimed uint64
+	// number of plain releases that don't hold any lease
+	// for plain calls that were rejected or whose leases were reclaimed
+	orphans uint64
+	lock    sync.Mutex
+}
+
+// NewThrottlerLease creates new throttler instance that
+// throttles each call which exeeds the running quota acquired - release
+// q defined by the specified threshold same way as `running` throttler does,
+// but each admitted call takes a lease with the specified ttl
+// and expired leases are reclaimed back to the running quota automatically.
+// Use `AcquireLease` to get the lease that could be renewed by long running holder
+// and released exactly, plain `Release` releases the oldest lease taken by plain `Acquire`
+// and never touches leases taken by `AcquireLease`.
+// Rejected calls don't take any lease, plain releases of rejected calls
+// and of reclaimed leases are balanced and never release other live leases.
+// If ttl is zero leases never expire.
+// Number of reclaimed leases is reported as `reclaimed` meta.
+func NewThrottlerLease(threshold uint64, ttl time.Duration) Leaser {
+	return &tlease{plain: list.New(), threshold: threshold, ttl: ttl}
+}
+
+func (thr *tlease) Acquire(context.Context) error {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	l, err := thr.acquire()
+	if err != nil {
+		thr.orphans++
+		return err
+	}
+	l.plain = thr.plain.PushBack(l)
+	return nil
+}
+
+func (thr *tlease) Release(context.Context) error {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	thr.reclaim()
+	// late releases of rejected calls and reclaimed leases
+	// are matched first so they never release live leases
+	if thr.orphans > 0 {
+		thr.orphans--
+		return nil
+	}
+	if front := thr.plain.Front(); front != nil {
+		thr.remove(front.Value.(*lease))
+	}
+	return nil
+}
+
+func (thr *tlease) AcquireLease(context.Context) (Lease, error) {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	return thr.acquire()
+}
+
+func (thr *tlease) acquireTicket(ctx context.Context, t *ticket) error {
+	l, err := thr.AcquireLease(ctx)
+	t.add(l.Release)
+	return err
+}
+
+func (thr *tlease) Meta() map[string]interface{} {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	thr.reclaim()
+	return map[string]interface{}{
+		"running":   uint64(thr.leases.Len()),
+		"threshold": thr.threshold,
+		"ttl":       thr.ttl,
+		"reclaimed": thr.reclaimed,
+	}
+}
+
+func (thr *tlease) Tune(param string, value float64) error {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	switch {
+	case param == "threshold" && valid(value, 0, math.MaxUint64):
+		thr.threshold = uint64(value)
+	case param == "ttl" && valid(value, 0, math.MaxInt64):
+		thr.ttl = time.Duration(value)
+	case param == "threshold" || param == "ttl":
+		return invalid(param, value)
+	default:
+		return untunable(param)
+	}
+	return nil
+}
+
+func (thr *tlease) Reset() {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	// outstanding leases are gone
+	for _, l := range thr.leases {
+		l.index, l.plain = -1, nil
+	}
+	thr.leases = nil
+	thr.plain.Init()
+	thr.reclaimed = 0
+	thr.orphans = 0
+}
+
+// acquire takes new lease or returns lease without running quota and error if running quota is drained.
+func (thr *tlease) acquire() (*lease, error) {
+	thr.reclaim()
+	l := &lease{thr: thr, expires: time.Now().UTC().Add(thr.ttl), index: -1}
+	if uint64(thr.leases.Len()) >= thr.threshold {
+		return l, errors.New("throttler has exceed running threshold")
+	}
+	heap.Push(&thr.leases, l)
+	return l, nil
+}
+
+// remove removes the provided lease if it isn't gone yet.
+func (thr *tlease) remove(l *lease) {
+	if l.index < 0 {
+		return
+	}
+	heap.Remove(&thr.leases, l.index)
+	if l.plain != nil {
+		thr.plain.Remove(l.plain)
+		l.plain = nil
+	}
+}
+
+// reclaim lazily removes all expired leases in expiry order.
+func (thr *tlease) reclaim() {
+	// leases without ttl never expire
+	if thr.ttl == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	for thr.leases.Len() > 0 && thr.leases[0].expires.Before(now) {
+		l := thr.leases[0]
+		if l.plain != nil {
+			thr.orphans++
+		}
+		thr.remove(l)
+		thr.reclaimed++
+		log("throttler has reclaimed expired lease %v", l.expires)
+	}
+}
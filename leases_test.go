@@ -0,0 +1,95 @@
+package gohalt
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	thr := NewThrottlerLease(2, time.Minute)
+	first, err := thr.AcquireLease(ctx)
+	assert.NoError(t, err)
+	second, err := thr.AcquireLease(ctx)
+	assert.NoError(t, err)
+	third, err := thr.AcquireLease(ctx)
+	assert.Equal(t, errors.New("throttler has exceed running threshold"), err)
+	// rejected lease doesn't hold any running quota
+	assert.NoError(t, third.Release(ctx))
+	assert.NoError(t, third.Release(ctx))
+	assert.Equal(t, errors.New("throttler hasn't found any lease"), third.Renew(ctx))
+	assert.Equal(t, uint64(2), thr.(Tunable).Meta()["running"])
+	expires := second.(*lease).expires
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, second.Renew(ctx))
+	assert.True(t, second.(*lease).expires.After(expires))
+	expire(first)
+	// only expired lease is reclaimed
+	meta := thr.(Tunable).Meta()
+	assert.Equal(t, uint64(1), meta["running"])
+	assert.Equal(t, uint64(1), meta["reclaimed"])
+	assert.Equal(t, errors.New("throttler hasn't found any lease"), first.Renew(ctx))
+	assert.NoError(t, first.Release(ctx))
+	assert.Equal(t, uint64(1), thr.(Tunable).Meta()["running"])
+	assert.NoError(t, second.Release(ctx))
+	assert.Equal(t, uint64(0), thr.(Tunable).Meta()["running"])
+}
+
+func TestLeaseReclaimed(t *testing.T) {
+	ctx := context.Background()
+	thr := NewThrottlerLease(1, time.Minute)
+	assert.NoError(t, thr.Acquire(ctx))
+	expire(thr.(*tlease).plain.Front().Value.(*lease))
+	assert.NoError(t, thr.Acquire(ctx))
+	// late release of reclaimed lease never releases live lease
+	assert.NoError(t, thr.Release(ctx))
+	assert.Equal(t, errors.New("throttler has exceed running threshold"), thr.Acquire(ctx))
+	assert.NoError(t, thr.Release(ctx))
+	assert.Equal(t, uint64(1), thr.(Tunable).Meta()["running"])
+	assert.NoError(t, thr.Release(ctx))
+	assert.Equal(t, uint64(0), thr.(Tunable).Meta()["running"])
+	assert.NoError(t, thr.Acquire(ctx))
+}
+
+func TestLeaseRelease(t *testing.T) {
+	ctx := context.Background()
+	thr := NewThrottlerLease(2, time.Minute)
+	holder, err := thr.AcquireLease(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, thr.Acquire(ctx))
+	assert.Equal(t, errors.New("throttler has exceed running threshold"), thr.Acquire(ctx))
+	// plain release never touches leases of long running holders
+	assert.NoError(t, thr.Release(ctx))
+	assert.NoError(t, thr.Release(ctx))
+	assert.Equal(t, uint64(1), thr.(Tunable).Meta()["running"])
+	assert.NoError(t, holder.Renew(ctx))
+	// tickets release exactly their own leases
+	tkt, err := AcquireTicket(ctx, thr)
+	assert.NoError(t, err)
+	rtkt, err := AcquireTicket(ctx, thr)
+	assert.Equal(t, errors.New("throttler has exceed running threshold"), err)
+	assert.NoError(t, rtkt.Release(ctx))
+	assert.Equal(t, uint64(2), thr.(Tunable).Meta()["running"])
+	assert.NoError(t, tkt.Release(ctx))
+	assert.Equal(t, uint64(1), thr.(Tunable).Meta()["running"])
+	assert.NoError(t, holder.Renew(ctx))
+	// reset leases are gone
+	thr.(Tunable).Reset()
+	assert.Equal(t, errors.New("throttler hasn't found any lease"), holder.Renew(ctx))
+	assert.NoError(t, holder.Release(ctx))
+	assert.NoError(t, thr.Acquire(ctx))
+	assert.Equal(t, uint64(1), thr.(Tunable).Meta()["running"])
+}
+
+func expire(l Lease) {
+	lt := l.(*lease)
+	lt.thr.lock.Lock()
+	defer lt.thr.lock.Unlock()
+	lt.expires = time.Now().UTC().Add(-time.Second)
+	heap.Fix(&lt.thr.leases, lt.index)
+}
@@ -21,7 +21,8 @@ func (p *percentiles) Len() int {
 func (p *percentiles) Push(dim uint64) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
-	if len(p.buf) >= int(p.cap) {
+	// zero capacity keeps only the last value
+	if len(p.buf) > 0 && len(p.buf) >= int(p.cap) {
 		p.buf = p.buf[1:]
 	}
 	p.buf = append(p.buf, dim)
@@ -30,6 +31,9 @@ func (p *percentiles) Push(dim uint64) {
 func (p *percentiles) At(pval float64) uint64 {
 	p.lock.Lock()
 	defer p.lock.Unlock()
+	if len(p.buf) == 0 {
+		return 0
+	}
 	buf := make([]uint64, len(p.buf))
 	_ = copy(buf, p.buf)
 	sort.Slice(buf, func(i, j int) bool {
@@ -0,0 +1,140 @@
+package gohalt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Transformer defined by typical abstract async func signature
+// that transforms the provided value into a new value.
+// Transformer is used by `Pipeline` as a stage handler.
+type Transformer func(context.Context, interface{}) (interface{}, error)
+
+// Pipeline defines multi stage pipeline builder and runner
+// where each stage transforms values with its own throttler and concurrency
+// and passes transformed values downstream through its own buffered channel.
+type Pipeline interface {
+	// Stage appends new stage that transforms values with the provided transformer
+	// with regard to the provided throttler on concurrency number of workers
+	// and passes them downstream through channel with capacity defined by the provided buffer.
+	Stage(thr Throttler, concurrency uint64, buffer uint64, transform Transformer) Pipeline
+	// Run starts all stages to process values from the provided input channel
+	// and returns the last stage output channel that needs to be drained.
+	// Once the pipeline is canceled the input channel is still drained and values are dropped
+	// until the input channel is closed, so the producer needs to close it eventually.
+	// Run should be called only once after all stages are appended.
+	Run(in <-chan interface{}) <-chan interface{}
+	// Result waits for all stages to finish and returns possible execution error back.
+	Result() error
+}
+
+type pstage struct {
+	thr         Throttler
+	concurrency uint64
+	buffer      uint64
+	transform   Transformer
+}
+
+type pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stages []pstage
+	wg     sync.WaitGroup
+	rep    *reporter
+}
+
+// NewPipeline creates new pipeline instance with regard to the provided context.
+// Backpressure flows upstream, as each stage blocks once its downstream channel is full,
+// and on stage error the whole pipeline is canceled.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error cancels the pipeline and is returned from result.
+// Use `WithPolicy` to specify errors handling policy.
+func NewPipeline(ctx context.Context) Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &pipeline{ctx: ctx, cancel: cancel, rep: newReporter(ctx, cancel, "pipeline")}
+}
+
+func (p *pipeline) Stage(thr Throttler, concurrency uint64, buffer uint64, transform Transformer) Pipeline {
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	p.stages = append(p.stages, pstage{
+		thr:         thr,
+		concurrency: concurrency,
+		buffer:      buffer,
+		transform:   transform,
+	})
+	return p
+}
+
+func (p *pipeline) Run(in <-chan interface{}) <-chan interface{} {
+	for _, stage := range p.stages {
+		in = p.run(stage, in)
+	}
+	return in
+}
+
+func (p *pipeline) Result() error {
+	p.wg.Wait()
+	p.cancel()
+	return p.rep.result()
+}
+
+func (p *pipeline) run(stage pstage, in <-chan interface{}) <-chan interface{} {
+	out := make(chan interface{}, stage.buffer)
+	var wg sync.WaitGroup
+	wg.Add(int(stage.concurrency))
+	p.wg.Add(int(stage.concurrency))
+	for i := uint64(0); i < stage.concurrency; i++ {
+		go func() {
+			defer p.wg.Done()
+			defer wg.Done()
+			p.work(stage, in, out)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+		// keep draining input after cancel until it is closed
+		// so upstream producers are never blocked on send
+		for range in {
+		}
+	}()
+	return out
+}
+
+func (p *pipeline) work(stage pstage, in <-chan interface{}, out chan<- interface{}) {
+	for {
+		var val interface{}
+		var ok bool
+		select {
+		case <-p.ctx.Done():
+			return
+		case val, ok = <-in:
+			if !ok {
+				return
+			}
+		}
+		var result interface{}
+		var failed bool
+		report := p.rep.next()
+		execute(p.ctx, stage.thr, func(ctx context.Context) (err error) {
+			result, err = stage.transform(ctx, val)
+			return err
+		}, func(err error) {
+			failed = true
+			report(err)
+		})
+		// failed values are never passed downstream
+		if failed {
+			continue
+		}
+		select {
+		case <-p.ctx.Done():
+			report(fmt.Errorf("context error has happened %w", p.ctx.Err()))
+			return
+		case out <- result:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelines(t *testing.T) {
+	testerr := errors.New("test")
+	incr := func(_ context.Context, val interface{}) (interface{}, error) {
+		return val.(int) + 1, nil
+	}
+	double := func(_ context.Context, val interface{}) (interface{}, error) {
+		return val.(int) * 2, nil
+	}
+	table := map[string]struct {
+		p    Pipeline
+		in   []int
+		out  []int
+		err  error
+		part bool
+	}{
+		"Pipeline should pass values through all stages": {
+			p: NewPipeline(context.Background()).
+				Stage(NewThrottlerEcho(nil), 2, 1, incr).
+				Stage(NewThrottlerRunning(1), 1, 0, double),
+			in:  []int{1, 2, 3, 4, 5},
+			out: []int{4, 6, 8, 10, 12},
+		},
+		"Pipeline should pass values without stages": {
+			p:   NewPipeline(context.Background()),
+			in:  []int{1, 2, 3},
+			out: []int{1, 2, 3},
+		},
+		"Pipeline should be canceled on stage error": {
+			p: NewPipeline(context.Background()).
+				Stage(NewThrottlerEcho(nil), 1, 0, incr).
+				Stage(NewThrottlerEcho(nil), 1, 0, func(_ context.Context, val interface{}) (interface{}, error) {
+					if val.(int) == 3 {
+						return nil, testerr
+					}
+					return val, nil
+				}),
+			in:   []int{1, 2, 3, 4, 5},
+			out:  []int{2},
+			err:  fmt.Errorf("runnable error has happened %w", testerr),
+			part: true,
+		},
+		"Pipeline should be canceled on stage throttler error": {
+			p: NewPipeline(context.Background()).
+				Stage(NewThrottlerEcho(nil), 1, 0, incr).
+				Stage(NewThrottlerEcho(testerr), 1, 0, double),
+			in:  []int{1, 2, 3},
+			err: fmt.Errorf("throttler error has happened %w", testerr),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			in := make(chan interface{}, len(tcase.in))
+			for _, val := range tcase.in {
+				in <- val
+			}
+			close(in)
+			var out []int
+			for val := range tcase.p.Run(in) {
+				out = append(out, val.(int))
+			}
+			sort.Ints(out)
+			if tcase.part {
+				// values before the error could be passed downstream
+				assert.Subset(t, tcase.out, out)
+			} else {
+				assert.Equal(t, tcase.out, out)
+			}
+			assert.Equal(t, tcase.err, tcase.p.Result())
+		})
+	}
+}
+
+func TestPipelineDrain(t *testing.T) {
+	testerr := errors.New("test")
+	p := NewPipeline(context.Background()).
+		Stage(NewThrottlerEcho(nil), 1, 0, func(context.Context, interface{}) (interface{}, error) {
+			return nil, testerr
+		})
+	in := make(chan interface{})
+	out := p.Run(in)
+	// producer doesn't select on the pipeline context
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(in)
+		for i := 0; i < 5; i++ {
+			in <- i
+		}
+	}()
+	for range out {
+	}
+	assert.Equal(t, fmt.Errorf("runnable error has happened %w", testerr), p.Result())
+	select {
+	case <-done:
+	case <-time.After(ms30_0):
+		assert.Fail(t, "producer is blocked after pipeline cancel")
+	}
+}
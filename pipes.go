@@ -0,0 +1,63 @@
+package gohalt
+
+import (
+	"context"
+)
+
+// Pipe returns output channel that passes values from the provided input channel
+// only once the provided throttler admits them with regard to the provided context.
+// Throttler is acquired before and released after passing each value downstream,
+// values rejected by the throttler are dropped.
+// Use throttlers that wait instead of throttling like `buffered`, `wait` or `retry`
+// to shape traffic without dropping values.
+// Output channel is closed once the input channel is closed or the context is done.
+func Pipe(ctx context.Context, thr Throttler, in <-chan interface{}) <-chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case val, ok := <-in:
+				if !ok {
+					return
+				}
+				execute(ctx, thr, func(ctx context.Context) error {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case out <- val:
+						return nil
+					}
+				}, func(err error) {
+					log("pipe has dropped value %v", err)
+				})
+			}
+		}
+	}()
+	return out
+}
+
+// ForEach runs the provided handler for each item from the provided items
+// on the fixed number of workers defined by the specified concurrency
+// with regard to the provided context and throttler and returns possible execution error back.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error cancels all remaining items and is returned.
+// Use `WithPolicy` to specify errors handling policy.
+func ForEach(
+	ctx context.Context,
+	thr Throttler,
+	items []interface{},
+	concurrency uint64,
+	handler func(context.Context, interface{}) error,
+) error {
+	r := NewRunnerPool(ctx, thr, concurrency, 0, OverflowBlock)
+	for _, item := range items {
+		item := item
+		r.Run(func(ctx context.Context) error {
+			return handler(ctx, item)
+		})
+	}
+	return r.Result()
+}
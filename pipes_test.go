@@ -0,0 +1,107 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipes(t *testing.T) {
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	table := map[string]struct {
+		ctx context.Context
+		thr Throttler
+		in  []interface{}
+		out []interface{}
+	}{
+		"Pipe should pass all admitted values": {
+			ctx: context.Background(),
+			thr: NewThrottlerBuffered(1),
+			in:  []interface{}{1, 2, 3},
+			out: []interface{}{1, 2, 3},
+		},
+		"Pipe should drop rejected values": {
+			ctx: context.Background(),
+			thr: NewThrottlerAfter(2),
+			in:  []interface{}{1, 2, 3, 4},
+			out: []interface{}{1, 2},
+		},
+		"Pipe should not pass values on canceled context": {
+			ctx: cctx,
+			thr: NewThrottlerEcho(nil),
+			in:  []interface{}{1, 2, 3},
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			in := make(chan interface{}, len(tcase.in))
+			for _, val := range tcase.in {
+				in <- val
+			}
+			close(in)
+			var out []interface{}
+			for val := range Pipe(tcase.ctx, tcase.thr, in) {
+				out = append(out, val)
+			}
+			assert.Equal(t, tcase.out, out)
+		})
+	}
+}
+
+func TestForEach(t *testing.T) {
+	testerr := errors.New("test")
+	table := map[string]struct {
+		ctx   context.Context
+		thr   Throttler
+		items []interface{}
+		fail  interface{}
+		seen  []int
+		err   error
+	}{
+		"ForEach should run handler for each item": {
+			ctx:   context.Background(),
+			thr:   NewThrottlerRunning(2),
+			items: []interface{}{1, 2, 3, 4},
+			seen:  []int{1, 2, 3, 4},
+		},
+		"ForEach should return throttler error": {
+			ctx:   context.Background(),
+			thr:   NewThrottlerEcho(testerr),
+			items: []interface{}{1, 2, 3},
+			err:   fmt.Errorf("throttler error has happened %w", testerr),
+		},
+		"ForEach should continue on handler error with continue policy": {
+			ctx:   WithPolicy(context.Background(), PolicyContinue),
+			thr:   NewThrottlerEcho(nil),
+			items: []interface{}{1, 2, 3},
+			fail:  2,
+			seen:  []int{1, 2, 3},
+			err:   MultiError{RunError{Index: 1, Err: fmt.Errorf("runnable error has happened %w", testerr)}},
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			seen := make(chan int, len(tcase.items))
+			err := ForEach(tcase.ctx, tcase.thr, tcase.items, 1, func(_ context.Context, item interface{}) error {
+				seen <- item.(int)
+				if item == tcase.fail {
+					return testerr
+				}
+				return nil
+			})
+			assert.Equal(t, tcase.err, err)
+			close(seen)
+			var items []int
+			for item := range seen {
+				items = append(items, item)
+			}
+			sort.Ints(items)
+			assert.Equal(t, tcase.seen, items)
+		})
+	}
+}
@@ -2,8 +2,13 @@ package gohalt
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 	"sync"
+	"time"
 )
 
 // Runner defines abstraction to execute a set of `Runnable`
@@ -13,129 +18,646 @@ import (
 type Runner interface {
 	// Run executes single prodived `Runnable` instance.
 	Run(Runnable)
+	// RunContext executes single prodived `Runnable` instance
+	// with the provided call context values merged with the runner context,
+	// the call is canceled either if the runner context or the call context is done.
+	RunContext(context.Context, Runnable)
 	// Result returns possible execution error back.
 	Result() error
 }
 
-type rsync struct {
-	thr    Throttler
-	ctx    context.Context
-	err    error
-	report func(error)
+// Fallback defines runnable that is executed by runners instead of `Runnable`
+// rejected by throttler and that receives typed throttle reason.
+// Use `WithFallback` to specify fallback for runner or for single call.
+type Fallback func(context.Context, ThrottleError) error
+
+// Policy defines runner errors handling policy.
+// Use `WithPolicy` to specify runner errors handling policy on runner creation.
+// Policy is read only once from the runner creation context,
+// policy from call context provided to `RunContext` is ignored,
+// so single call can never change the whole runner policy.
+type Policy struct {
+	limit     uint64
+	aggregate bool
 }
 
-// NewRunnerSync creates synchronous runner instance
-// that runs a set of `Runnable` consecutively
-// with regard to the provided context and throttler.
-// First occurred error is returned from result.
-func NewRunnerSync(ctx context.Context, thr Throttler) Runner {
-	ctx, cancel := context.WithCancel(ctx)
-	r := rsync{thr: thr, ctx: ctx}
-	r.report = func(err error) {
-		if err != nil {
-			if r.err == nil {
-				r.err = err
-				cancel()
-			}
-			log("sync runner error happened %v", err)
-		}
+var (
+	// PolicyFailFast stops runner on the first error and returns only this error back.
+	// PolicyFailFast is used by runners by default.
+	PolicyFailFast = Policy{limit: 1}
+	// PolicyContinue never stops runner on errors
+	// and returns all errors back aggregated into `MultiError`.
+	PolicyContinue = Policy{aggregate: true}
+)
+
+// PolicyStopAfter creates policy that stops runner after the errors number
+// defined by the specified limit is reached and returns all errors back aggregated into `MultiError`.
+// Zero limit is treated as `PolicyFailFast`.
+func PolicyStopAfter(limit uint64) Policy {
+	if limit == 0 {
+		return PolicyFailFast
 	}
-	return &r
+	return Policy{limit: limit, aggregate: true}
 }
 
-func (r *rsync) Run(run Runnable) {
-	select {
-	case <-r.ctx.Done():
-		r.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
-		return
-	default:
+type reporter struct {
+	policy Policy
+	name   string
+	cancel context.CancelFunc
+	index  uint64
+	errs   MultiError
+	// size limits number of kept most recent errors if not zero
+	size    uint64
+	count   uint64
+	stopped bool
+	lock    sync.Mutex
+}
+
+func newReporter(ctx context.Context, cancel context.CancelFunc, name string) *reporter {
+	return &reporter{policy: ctxPolicy(ctx), name: name, cancel: cancel}
+}
+
+// next returns report func for the next submitted `Runnable`.
+func (rep *reporter) next() func(error) {
+	index := atomicIncr(&rep.index) - 1
+	return func(err error) {
+		rep.report(index, err)
 	}
-	defer func() {
-		if err := r.thr.Release(r.ctx); err != nil {
-			r.report(fmt.Errorf("throttler error has happened %w", err))
-		}
-	}()
-	if err := r.thr.Acquire(r.ctx); err != nil {
-		r.report(fmt.Errorf("throttler error has happened %w", err))
+}
+
+func (rep *reporter) report(index uint64, err error) {
+	if err == nil {
 		return
 	}
-	select {
-	case <-r.ctx.Done():
-		r.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
+	log("%s runner error happened %v", rep.name, err)
+	rep.lock.Lock()
+	defer rep.lock.Unlock()
+	// errors after stop are caused by stop itself
+	if rep.stopped {
 		return
-	default:
 	}
-	if err := run(r.ctx); err != nil {
-		r.report(fmt.Errorf("runnable error has happened %w", err))
-		return
+	if rep.size > 0 && uint64(len(rep.errs)) >= rep.size {
+		// drop the oldest error and reuse the same backing array
+		rep.errs = rep.errs[:copy(rep.errs, rep.errs[1:])]
+	}
+	rep.errs = append(rep.errs, RunError{Index: index, Err: err})
+	rep.count++
+	if rep.policy.limit > 0 && rep.count >= rep.policy.limit {
+		rep.stopped = true
+		rep.cancel()
+	}
+}
+
+func (rep *reporter) result() error {
+	rep.lock.Lock()
+	defer rep.lock.Unlock()
+	if len(rep.errs) == 0 {
+		return nil
+	}
+	if !rep.policy.aggregate {
+		return rep.errs[0].Err
 	}
+	errs := make(MultiError, len(rep.errs))
+	_ = copy(errs, rep.errs)
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Index < errs[j].Index
+	})
+	return errs
+}
+
+type rsync struct {
+	thr Throttler
+	ctx context.Context
+	rep *reporter
+}
+
+// NewRunnerSync creates synchronous runner instance
+// that runs a set of `Runnable` consecutively
+// with regard to the provided context and throttler.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error is returned from result.
+// Use `WithPolicy` to specify errors handling policy.
+func NewRunnerSync(ctx context.Context, thr Throttler) Runner {
+	ctx, cancel := context.WithCancel(ctx)
+	return &rsync{thr: thr, ctx: ctx, rep: newReporter(ctx, cancel, "sync")}
+}
+
+func (r *rsync) Run(run Runnable) {
+	execute(r.ctx, r.thr, run, r.rep.next())
+}
+
+func (r *rsync) RunContext(ctx context.Context, run Runnable) {
+	ctx, cancel := withMerge(r.ctx, ctx)
+	defer cancel()
+	execute(ctx, r.thr, run, r.rep.next())
 }
 
 func (r *rsync) Result() error {
-	return r.err
+	return r.rep.result()
 }
 
 type rasync struct {
-	thr    Throttler
-	ctx    context.Context
-	wg     sync.WaitGroup
-	err    error
-	report func(error)
+	thr Throttler
+	ctx context.Context
+	wg  sync.WaitGroup
+	rep *reporter
 }
 
 // NewRunnerAsync creates asynchronous runner instance
 // that runs a set of `Runnable` simultaneously
 // with regard to the provided context and throttler.
-// First occurred error is returned from result.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error is returned from result.
+// Use `WithPolicy` to specify errors handling policy.
 func NewRunnerAsync(ctx context.Context, thr Throttler) Runner {
 	ctx, cancel := context.WithCancel(ctx)
-	r := rasync{thr: thr, ctx: ctx}
-	var once sync.Once
-	r.report = func(err error) {
-		if err != nil {
-			once.Do(func() {
-				r.err = err
-				cancel()
-			})
-			log("async runner error happened %v", err)
-		}
-	}
-	return &r
+	return &rasync{thr: thr, ctx: ctx, rep: newReporter(ctx, cancel, "async")}
 }
 
 func (r *rasync) Run(run Runnable) {
 	r.wg.Add(1)
+	report := r.rep.next()
+	go func() {
+		defer r.wg.Done()
+		execute(r.ctx, r.thr, run, report)
+	}()
+}
+
+func (r *rasync) RunContext(ctx context.Context, run Runnable) {
+	r.wg.Add(1)
+	report := r.rep.next()
 	go func() {
 		defer r.wg.Done()
+		ctx, cancel := withMerge(r.ctx, ctx)
+		defer cancel()
+		execute(ctx, r.thr, run, report)
+	}()
+}
+
+func (r *rasync) Result() error {
+	r.wg.Wait()
+	return r.rep.result()
+}
+
+// Overflow defines pool runner submission queue overflow policy.
+type Overflow uint8
+
+const (
+	// OverflowBlock blocks submission until the queue has free space again.
+	OverflowBlock Overflow = iota
+	// OverflowDrop silently drops submission.
+	OverflowDrop
+	// OverflowFail drops submission and reports queue overflow error.
+	OverflowFail
+)
+
+type rtask struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	run    Runnable
+	report func(error)
+}
+
+type rpool struct {
+	thr      Throttler
+	ctx      context.Context
+	cancel   context.CancelFunc
+	queue    chan rtask
+	stop     chan struct{}
+	stopped  bool
+	overflow Overflow
+	wg       sync.WaitGroup
+	lock     sync.RWMutex
+	rep      *reporter
+}
+
+// NewRunnerPool creates pooled asynchronous runner instance
+// that runs a set of `Runnable` simultaneously on the fixed number of workers
+// defined by the specified workers with regard to the provided context and throttler.
+// Submitted `Runnable` instances wait for free worker in the bounded queue
+// with capacity defined by the specified queue, if the queue is full
+// submission either blocks, drops or fails accordingly to the specified overflow policy.
+// Result waits for all submitted `Runnable` instances and stops pool workers,
+// so any subsequent submission will fail with context error.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error is returned from result.
+// Use `WithPolicy` to specify errors handling policy.
+func NewRunnerPool(ctx context.Context, thr Throttler, workers uint64, queue uint64, overflow Overflow) Runner {
+	if workers == 0 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &rpool{
+		thr:      thr,
+		ctx:      ctx,
+		cancel:   cancel,
+		queue:    make(chan rtask, queue),
+		stop:     make(chan struct{}),
+		overflow: overflow,
+		rep:      newReporter(ctx, cancel, "pool"),
+	}
+	for i := uint64(0); i < workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+func (r *rpool) Run(run Runnable) {
+	r.submit(rtask{ctx: r.ctx, run: run, report: r.rep.next()})
+}
+
+func (r *rpool) RunContext(ctx context.Context, run Runnable) {
+	ctx, cancel := withMerge(r.ctx, ctx)
+	r.submit(rtask{ctx: ctx, cancel: cancel, run: run, report: r.rep.next()})
+}
+
+func (r *rpool) Result() error {
+	r.wg.Wait()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.stopped {
+		r.stopped = true
+		r.cancel()
+		close(r.stop)
+	}
+	return r.rep.result()
+}
+
+func (r *rpool) submit(task rtask) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	r.wg.Add(1)
+	if r.stopped {
+		task.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
+		r.done(task)
+		return
+	}
+	select {
+	case <-r.ctx.Done():
+		task.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
+		r.done(task)
+		return
+	case r.queue <- task:
+		return
+	default:
+	}
+	switch r.overflow {
+	case OverflowDrop:
+		r.done(task)
+		log("pool runner queue overflow happened")
+	case OverflowFail:
+		task.report(errors.New("pool runner queue has overflowed"))
+		r.done(task)
+	default:
 		select {
 		case <-r.ctx.Done():
-			r.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
+			task.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
+			r.done(task)
+		case r.queue <- task:
+		}
+	}
+}
+
+func (r *rpool) work() {
+	for {
+		select {
+		case <-r.stop:
 			return
-		default:
+		case task := <-r.queue:
+			execute(task.ctx, r.thr, task.run, task.report)
+			r.done(task)
+		}
+	}
+}
+
+func (r *rpool) done(task rtask) {
+	if task.cancel != nil {
+		task.cancel()
+	}
+	r.wg.Done()
+}
+
+type rhedged struct {
+	Runner
+	hedge      Throttler
+	latencies  *percentiles
+	percentile float64
+}
+
+// NewRunnerHedged creates asynchronous hedged runner instance
+// that runs a set of `Runnable` simultaneously with regard to the provided context and throttler
+// and fires second copy of `Runnable` if the first one hasn't finished
+// after the current latency percentile defined by the specified percentile.
+// Latencies of successful runs are kept in bounded buffer with capacity c defined by the specified capacity,
+// no copies are fired until at least one latency is observed.
+// Each copy needs to be admitted by the provided hedge throttler first,
+// so hedging doesn't overload the backend.
+// First successful copy wins and the other copy is canceled,
+// so only idempotent `Runnable` should be used with hedged runner.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error is returned from result.
+// Use `WithPolicy` to specify errors handling policy.
+func NewRunnerHedged(
+	ctx context.Context,
+	thr Throttler,
+	hedge Throttler,
+	capacity uint8,
+	percentile float64,
+) Runner {
+	percentile = math.Abs(percentile)
+	if percentile > 1.0 {
+		percentile = 1.0
+	}
+	latencies := &percentiles{cap: capacity}
+	latencies.Prune()
+	return &rhedged{
+		Runner:     NewRunnerAsync(ctx, thr),
+		hedge:      hedge,
+		latencies:  latencies,
+		percentile: percentile,
+	}
+}
+
+func (r *rhedged) Run(run Runnable) {
+	r.Runner.Run(r.hedged(run))
+}
+
+func (r *rhedged) RunContext(ctx context.Context, run Runnable) {
+	r.Runner.RunContext(ctx, r.hedged(run))
+}
+
+func (r *rhedged) hedged(run Runnable) Runnable {
+	type attempt struct {
+		err error
+		ts  time.Time
+	}
+	return func(ctx context.Context) error {
+		hctx, cancel := context.WithCancel(ctx)
+		// cancel the loser copy
+		defer cancel()
+		attempts := make(chan attempt, 2)
+		launch := func() {
+			ts := time.Now().UTC()
+			go func() {
+				err := catch(func() error { return run(hctx) })
+				attempts <- attempt{err: err, ts: ts}
+			}()
 		}
-		defer func() {
-			if err := r.thr.Release(r.ctx); err != nil {
-				r.report(fmt.Errorf("throttler error has happened %w", err))
+		launch()
+		running := 1
+		if r.latencies.Len() > 0 {
+			timer := time.NewTimer(time.Duration(r.latencies.At(r.percentile)))
+			defer timer.Stop()
+			select {
+			case a := <-attempts:
+				return r.observe(a.err, a.ts)
+			case <-timer.C:
+			}
+			t := &ticket{}
+			defer func() {
+				if err := catch(func() error { return t.Release(ctx) }); err != nil {
+					log("hedged runner hedge error happened %v", err)
+				}
+			}()
+			if err := catch(func() error { return t.acquire(ctx, r.hedge) }); err != nil {
+				log("hedged runner hedge is throttled %v", err)
+			} else {
+				launch()
+				running++
+			}
+		}
+		var err error
+		for ; running > 0; running-- {
+			a := <-attempts
+			if err = r.observe(a.err, a.ts); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+}
+
+func (r *rhedged) observe(err error, ts time.Time) error {
+	if err == nil {
+		r.latencies.Push(uint64(time.Since(ts)))
+	}
+	return err
+}
+
+// Overlap defines scheduled runner policy for run
+// that overlaps with still running previous run of the same `Runnable`.
+type Overlap uint8
+
+const (
+	// OverlapSkip skips overlapping run.
+	OverlapSkip Overlap = iota
+	// OverlapQueue queues overlapping run until previous runs are finished.
+	OverlapQueue
+	// OverlapConcurrent runs overlapping run concurrently with previous runs.
+	OverlapConcurrent
+)
+
+// DefaultScheduledErrors defines default number of the most recent errors
+// kept and returned by `scheduled` runner, older errors are dropped.
+// By default DefaultScheduledErrors is set to use `100`.
+var DefaultScheduledErrors uint64 = 100
+
+type rscheduled struct {
+	thr      Throttler
+	ctx      context.Context
+	cancel   context.CancelFunc
+	schedule Schedule
+	overlap  Overlap
+	jitter   time.Duration
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+	rep      *reporter
+}
+
+// NewRunnerScheduled creates scheduled runner instance
+// that runs each `Runnable` repeatedly accordingly to the provided schedule
+// with regard to the provided context and throttler until result is requested.
+// Each run is delayed by random jitter in range [0, j) defined by the specified jitter
+// and overlapping runs are either skipped, queued or run concurrently accordingly to the specified overlap policy.
+// Use builtin `NewScheduleEvery` to create fixed interval schedule instance
+// or `NewScheduleCron` to create cron expression schedule instance.
+// Result stops all schedules, waits for all started runs and returns possible execution error back.
+// Errors are handled accordingly to the context errors handling policy,
+// by default first occurred error stops all schedules and is returned from result.
+// Use `WithPolicy` to specify errors handling policy,
+// note that only `DefaultScheduledErrors` most recent errors are kept and returned.
+func NewRunnerScheduled(
+	ctx context.Context,
+	thr Throttler,
+	schedule Schedule,
+	overlap Overlap,
+	jitter time.Duration,
+) Runner {
+	ctx, cancel := context.WithCancel(ctx)
+	rep := newReporter(ctx, cancel, "scheduled")
+	rep.size = DefaultScheduledErrors
+	return &rscheduled{
+		thr:      thr,
+		ctx:      ctx,
+		cancel:   cancel,
+		schedule: schedule,
+		overlap:  overlap,
+		jitter:   jitter,
+		stop:     make(chan struct{}),
+		rep:      rep,
+	}
+}
+
+func (r *rscheduled) Run(run Runnable) {
+	r.wg.Add(1)
+	go r.repeat(r.ctx, nil, run)
+}
+
+func (r *rscheduled) RunContext(ctx context.Context, run Runnable) {
+	r.wg.Add(1)
+	ctx, cancel := withMerge(r.ctx, ctx)
+	go r.repeat(ctx, cancel, run)
+}
+
+func (r *rscheduled) Result() error {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	r.wg.Wait()
+	r.cancel()
+	return r.rep.result()
+}
+
+// repeat runs provided `Runnable` accordingly to the schedule until the schedule is stopped.
+// Note that neither `loop` nor `delayed` executors are used here,
+// `loop` ticks only with fixed period and can't follow cron schedule or jitter,
+// and both of them sleep ignoring the schedule stop, which would block result until the next run.
+func (r *rscheduled) repeat(ctx context.Context, cancel context.CancelFunc, run Runnable) {
+	defer r.wg.Done()
+	if cancel != nil {
+		defer cancel()
+	}
+	var running, pending uint64
+	wake := make(chan struct{}, 1)
+	exec := func() {
+		defer r.wg.Done()
+		execute(ctx, r.thr, run, r.rep.next())
+	}
+	if r.overlap == OverlapQueue {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for {
+				select {
+				case <-r.stop:
+					return
+				case <-ctx.Done():
+					return
+				case <-wake:
+				}
+				for atomicCDecr(&pending) {
+					// drop queued runs once schedule is stopped
+					select {
+					case <-r.stop:
+						return
+					case <-ctx.Done():
+						return
+					default:
+					}
+					r.wg.Add(1)
+					exec()
+				}
 			}
 		}()
-		if err := r.thr.Acquire(r.ctx); err != nil {
-			r.report(fmt.Errorf("throttler error has happened %w", err))
+	}
+	ts := time.Now()
+	for {
+		// don't catch up missed runs
+		if ts = r.schedule.Next(ts); ts.Before(time.Now()) {
+			ts = r.schedule.Next(time.Now())
+		}
+		if ts.IsZero() {
+			log("scheduled runner schedule hasn't found any next run")
 			return
 		}
+		delay := time.Until(ts)
+		if r.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(r.jitter)))
+		}
+		timer := time.NewTimer(delay)
 		select {
-		case <-r.ctx.Done():
-			r.report(fmt.Errorf("context error has happened %w", r.ctx.Err()))
+		case <-r.stop:
+			timer.Stop()
 			return
-		default:
-		}
-		if err := run(r.ctx); err != nil {
-			r.report(fmt.Errorf("runnable error has happened %w", err))
+		case <-ctx.Done():
+			timer.Stop()
 			return
+		case <-timer.C:
 		}
-	}()
+		switch r.overlap {
+		case OverlapSkip:
+			if atomicBIncr(&running) > 1 {
+				atomicBDecr(&running)
+				log("scheduled runner overlapping run is skipped")
+				continue
+			}
+			r.wg.Add(1)
+			go func() {
+				defer atomicBDecr(&running)
+				exec()
+			}()
+		case OverlapQueue:
+			atomicIncr(&pending)
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		default:
+			r.wg.Add(1)
+			go exec()
+		}
+	}
 }
 
-func (r *rasync) Result() error {
-	r.wg.Wait()
-	return r.err
+// execute runs single provided `Runnable` with regard to the provided context and throttler
+// by managing `Acquire`/`Release` loop and reports all occurred errors to the provided report.
+// If the throttler rejects the call and the context has fallback, fallback is run instead.
+// All throttler and runnable panics are recovered and reported as `PanicError`.
+func execute(ctx context.Context, thr Throttler, run Runnable, report func(error)) {
+	select {
+	case <-ctx.Done():
+		report(fmt.Errorf("context error has happened %w", ctx.Err()))
+		return
+	default:
+	}
+	// release exactly the quota taken by acquire
+	// even if throttler has panicked in between
+	t := &ticket{}
+	defer func() {
+		if err := catch(func() error { return t.Release(ctx) }); err != nil {
+			report(fmt.Errorf("throttler error has happened %w", err))
+		}
+	}()
+	if err := catch(func() error { return t.acquire(ctx, thr) }); err != nil {
+		// degrade gracefully instead of reporting throttling
+		if fallback := ctxFallback(ctx); fallback != nil {
+			if err := catch(func() error { return fallback(ctx, newThrottleError(err, t.rejecter(thr))) }); err != nil {
+				report(fmt.Errorf("fallback error has happened %w", err))
+			}
+			return
+		}
+		report(fmt.Errorf("throttler error has happened %w", err))
+		return
+	}
+	select {
+	case <-ctx.Done():
+		report(fmt.Errorf("context error has happened %w", ctx.Err()))
+		return
+	default:
+	}
+	if err := catch(func() error { return run(ctx) }); err != nil {
+		report(fmt.Errorf("runnable error has happened %w", err))
+		return
+	}
 }
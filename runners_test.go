@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,8 +14,12 @@ import (
 func TestRunners(t *testing.T) {
 	cctx, cancel := context.WithCancel(context.Background())
 	cancel()
+	dctx, dcancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer dcancel()
+	kthr := NewThrottlerPattern(Pattern{Pattern: regexp.MustCompile(`^test$`), Throttler: tmock{}})
 	table := map[string]struct {
 		r   Runner
+		ctx context.Context
 		run Runnable
 		err error
 	}{
@@ -37,6 +43,23 @@ func TestRunners(t *testing.T) {
 			run: nope,
 			err: fmt.Errorf("context error has happened %w", cctx.Err()),
 		},
+		"Runner sync should use call context values": {
+			r:   NewRunnerSync(context.Background(), kthr),
+			ctx: WithKey(context.Background(), "test"),
+			run: nope,
+		},
+		"Runner sync should return error on runner context values": {
+			r:   NewRunnerSync(WithKey(context.Background(), "test"), kthr),
+			ctx: WithKey(context.Background(), "unknown"),
+			run: nope,
+			err: fmt.Errorf("throttler error has happened %w", errors.New("throttler hasn't found any key")),
+		},
+		"Runner sync should return error on canceled call context": {
+			r:   NewRunnerSync(context.Background(), tmock{}),
+			ctx: cctx,
+			run: nope,
+			err: fmt.Errorf("context error has happened %w", cctx.Err()),
+		},
 		"Runner async should return error on throttling": {
 			r:   NewRunnerAsync(context.Background(), tmock{aerr: errors.New("test")}),
 			run: nope,
@@ -57,12 +80,375 @@ func TestRunners(t *testing.T) {
 			run: nope,
 			err: fmt.Errorf("context error has happened %w", cctx.Err()),
 		},
+		"Runner async should use call context values": {
+			r:   NewRunnerAsync(context.Background(), kthr),
+			ctx: WithKey(context.Background(), "test"),
+			run: nope,
+		},
+		"Runner async should return error on canceled call context": {
+			r:   NewRunnerAsync(context.Background(), tmock{}),
+			ctx: cctx,
+			run: nope,
+			err: fmt.Errorf("context error has happened %w", cctx.Err()),
+		},
+		"Runner async should return deadline error on expired call context": {
+			r:   NewRunnerAsync(context.Background(), tmock{}),
+			ctx: dctx,
+			run: nope,
+			err: fmt.Errorf("context error has happened %w", context.DeadlineExceeded),
+		},
+		"Runner pool should return error on throttling": {
+			r:   NewRunnerPool(context.Background(), tmock{aerr: errors.New("test")}, 2, 2, OverflowBlock),
+			run: nope,
+			err: fmt.Errorf("throttler error has happened %w", errors.New("test")),
+		},
+		"Runner pool should return error on realising error": {
+			r:   NewRunnerPool(context.Background(), tmock{rerr: errors.New("test")}, 2, 2, OverflowBlock),
+			run: nope,
+			err: fmt.Errorf("throttler error has happened %w", errors.New("test")),
+		},
+		"Runner pool should return error on runnable error": {
+			r:   NewRunnerPool(context.Background(), tmock{}, 2, 2, OverflowBlock),
+			run: use(errors.New("test")),
+			err: fmt.Errorf("runnable error has happened %w", errors.New("test")),
+		},
+		"Runner pool should return error on canceled context": {
+			r:   NewRunnerPool(cctx, tmock{}, 2, 2, OverflowBlock),
+			run: nope,
+			err: fmt.Errorf("context error has happened %w", cctx.Err()),
+		},
+		"Runner pool should use call context values": {
+			r:   NewRunnerPool(context.Background(), kthr, 2, 0, OverflowBlock),
+			ctx: WithKey(context.Background(), "test"),
+			run: nope,
+		},
 	}
 	for tname, tcase := range table {
 		t.Run(tname, func(t *testing.T) {
-			tcase.r.Run(tcase.run)
+			if tcase.ctx != nil {
+				tcase.r.RunContext(tcase.ctx, tcase.run)
+			} else {
+				tcase.r.Run(tcase.run)
+			}
 			err := tcase.r.Result()
 			assert.Equal(t, tcase.err, err)
 		})
 	}
 }
+
+func TestRunnerPoolOverflow(t *testing.T) {
+	table := map[string]struct {
+		overflow Overflow
+		runs     uint64
+		err      error
+	}{
+		"Runner pool should drop on overflow": {
+			overflow: OverflowDrop,
+			runs:     2,
+		},
+		"Runner pool should fail on overflow": {
+			overflow: OverflowFail,
+			runs:     1,
+			err:      errors.New("pool runner queue has overflowed"),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			r := NewRunnerPool(context.Background(), tmock{}, 1, 1, tcase.overflow)
+			started, unblock := make(chan struct{}), make(chan struct{})
+			var runs uint64
+			r.Run(func(context.Context) error {
+				close(started)
+				<-unblock
+				atomicIncr(&runs)
+				return nil
+			})
+			<-started
+			for i := 0; i < 3; i++ {
+				r.Run(func(context.Context) error {
+					atomicIncr(&runs)
+					return nil
+				})
+			}
+			close(unblock)
+			assert.Equal(t, tcase.err, r.Result())
+			assert.Equal(t, tcase.runs, atomicGet(&runs))
+		})
+	}
+}
+
+func TestRunnerPolicies(t *testing.T) {
+	runs := []Runnable{
+		nope,
+		use(errors.New("test")),
+		func(context.Context) error {
+			panic("test")
+		},
+		use(errors.New("test")),
+	}
+	table := map[string]struct {
+		r    Runner
+		idxs []uint64
+	}{
+		"Runner sync should aggregate all errors with continue policy": {
+			r:    NewRunnerSync(WithPolicy(context.Background(), PolicyContinue), tmock{}),
+			idxs: []uint64{1, 2, 3},
+		},
+		"Runner sync should aggregate errors before stop with stop after policy": {
+			r:    NewRunnerSync(WithPolicy(context.Background(), PolicyStopAfter(2)), tmock{}),
+			idxs: []uint64{1, 2},
+		},
+		"Runner async should aggregate all errors with continue policy": {
+			r:    NewRunnerAsync(WithPolicy(context.Background(), PolicyContinue), tmock{}),
+			idxs: []uint64{1, 2, 3},
+		},
+		"Runner pool should aggregate all errors with continue policy": {
+			r:    NewRunnerPool(WithPolicy(context.Background(), PolicyContinue), tmock{}, 1, 4, OverflowBlock),
+			idxs: []uint64{1, 2, 3},
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			for _, run := range runs {
+				tcase.r.Run(run)
+			}
+			var merr MultiError
+			assert.True(t, errors.As(tcase.r.Result(), &merr))
+			idxs := make([]uint64, 0, len(merr))
+			for _, err := range merr {
+				idxs = append(idxs, err.Index)
+				var perr PanicError
+				assert.Equal(t, err.Index == 2, errors.As(err, &perr))
+			}
+			assert.Equal(t, tcase.idxs, idxs)
+		})
+	}
+}
+
+func TestRunnerPolicyPrecedence(t *testing.T) {
+	assert.Equal(t, PolicyFailFast, PolicyStopAfter(0))
+	testerr := errors.New("test")
+	r := NewRunnerSync(context.Background(), tmock{})
+	// call context policy doesn't change runner policy
+	r.RunContext(WithPolicy(context.Background(), PolicyContinue), use(testerr))
+	r.Run(use(errors.New("unreachable")))
+	assert.Equal(t, fmt.Errorf("runnable error has happened %w", testerr), r.Result())
+}
+
+func TestRunnerPanics(t *testing.T) {
+	r := NewRunnerSync(context.Background(), NewThrottlerPanic())
+	r.Run(nope)
+	var perr PanicError
+	assert.True(t, errors.As(r.Result(), &perr))
+	assert.Equal(t, "throttler has reached panic", perr.Value)
+	assert.NotEmpty(t, perr.Stack)
+}
+
+func TestRunnerFallback(t *testing.T) {
+	testerr := errors.New("test")
+	var reason ThrottleError
+	ctx := WithFallback(context.Background(), func(_ context.Context, terr ThrottleError) error {
+		reason = terr
+		return nil
+	})
+	echo := NewThrottlerEcho(testerr)
+	r := NewRunnerSync(ctx, echo)
+	r.Run(func(context.Context) error {
+		return errors.New("unreachable")
+	})
+	assert.NoError(t, r.Result())
+	assert.Equal(t, ThrottleError{Err: testerr, Reason: ReasonRejected, Throttler: echo}, reason)
+	assert.True(t, errors.Is(reason, testerr))
+	r = NewRunnerSync(ctx, NewThrottlerAny(NewThrottlerEcho(nil), echo))
+	r.Run(nope)
+	assert.NoError(t, r.Result())
+	assert.Equal(t, echo, reason.Throttler)
+	drn := NewThrottlerDrain()
+	drn.Drain()
+	r = NewRunnerSync(ctx, drn)
+	r.Run(nope)
+	assert.NoError(t, r.Result())
+	assert.Equal(t, ThrottleError{Err: ErrDrained, Reason: ReasonDrained, Throttler: drn}, reason)
+	r = NewRunnerSync(ctx, NewThrottlerPanic())
+	r.Run(nope)
+	assert.NoError(t, r.Result())
+	assert.Equal(t, ReasonPanicked, reason.Reason)
+	r = NewRunnerSync(context.Background(), NewThrottlerEcho(testerr))
+	r.RunContext(WithFallback(context.Background(), func(context.Context, ThrottleError) error {
+		return testerr
+	}), nope)
+	assert.Equal(t, fmt.Errorf("fallback error has happened %w", testerr), r.Result())
+	r = NewRunnerSync(ctx, NewThrottlerEcho(nil))
+	r.Run(func(context.Context) error {
+		return testerr
+	})
+	assert.Equal(t, fmt.Errorf("runnable error has happened %w", testerr), r.Result())
+	r = NewRunnerSync(WithFallback(context.Background(), func(context.Context, ThrottleError) error {
+		panic("test")
+	}), NewThrottlerEcho(testerr))
+	r.Run(nope)
+	var perr PanicError
+	assert.True(t, errors.As(r.Result(), &perr))
+}
+
+func TestRunnerHedged(t *testing.T) {
+	testerr := errors.New("test")
+	table := map[string]struct {
+		hedge    Throttler
+		calls    uint64
+		canceled uint64
+		over     time.Duration
+		pass     time.Duration
+	}{
+		"Hedged runner should fire admitted hedge and cancel the loser": {
+			hedge:    NewThrottlerEcho(nil),
+			calls:    2,
+			canceled: 1,
+			pass:     ms10_0,
+		},
+		"Hedged runner should not fire throttled hedge": {
+			hedge: NewThrottlerEcho(testerr),
+			calls: 1,
+			over:  ms10_0,
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			r := NewRunnerHedged(context.Background(), NewThrottlerEcho(nil), tcase.hedge, 10, 0.5)
+			// observe the first latency
+			r.Run(func(context.Context) error {
+				time.Sleep(ms1_0)
+				return nil
+			})
+			assert.NoError(t, r.Result())
+			var calls, canceled uint64
+			ts := time.Now()
+			r.Run(func(ctx context.Context) error {
+				if atomicIncr(&calls) > 1 {
+					return nil
+				}
+				select {
+				case <-ctx.Done():
+					atomicIncr(&canceled)
+					return ctx.Err()
+				case <-time.After(ms30_0):
+					return nil
+				}
+			})
+			assert.NoError(t, r.Result())
+			dur := time.Since(ts)
+			if tcase.over > 0 {
+				assert.Less(t, int64(tcase.over), int64(dur))
+			}
+			if tcase.pass > 0 {
+				assert.Less(t, int64(dur), int64(tcase.pass))
+			}
+			time.Sleep(ms1_0)
+			assert.Equal(t, tcase.calls, atomicGet(&calls))
+			assert.Equal(t, tcase.canceled, atomicGet(&canceled))
+		})
+	}
+}
+
+func TestRunnerHedgedZeroCapacity(t *testing.T) {
+	r := NewRunnerHedged(context.Background(), NewThrottlerEcho(nil), NewThrottlerEcho(nil), 0, 0.5)
+	for i := 0; i < 3; i++ {
+		r.Run(nope)
+	}
+	assert.NoError(t, r.Result())
+}
+
+func TestRunnerScheduled(t *testing.T) {
+	testerr := errors.New("test")
+	table := map[string]struct {
+		thr     Throttler
+		overlap Overlap
+		min     uint64
+		max     uint64
+		maxconc uint64
+		err     error
+	}{
+		"Scheduled runner should skip overlapping runs": {
+			thr:     NewThrottlerEcho(nil),
+			overlap: OverlapSkip,
+			min:     2,
+			max:     6,
+			maxconc: 1,
+		},
+		"Scheduled runner should queue overlapping runs": {
+			thr:     NewThrottlerEcho(nil),
+			overlap: OverlapQueue,
+			min:     4,
+			max:     7,
+			maxconc: 1,
+		},
+		"Scheduled runner should run overlapping runs concurrently": {
+			thr:     NewThrottlerEcho(nil),
+			overlap: OverlapConcurrent,
+			min:     5,
+			max:     16,
+			maxconc: 2,
+		},
+		"Scheduled runner should stop on throttler error": {
+			thr:     NewThrottlerEcho(testerr),
+			overlap: OverlapConcurrent,
+			err:     fmt.Errorf("throttler error has happened %w", testerr),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			r := NewRunnerScheduled(context.Background(), tcase.thr, NewScheduleEvery(ms2_0), tcase.overlap, 0)
+			var calls, running, maxconc uint64
+			r.Run(func(context.Context) error {
+				atomicIncr(&calls)
+				conc := atomicIncr(&running)
+				defer atomicBDecr(&running)
+				for prev := atomicGet(&maxconc); conc > prev; prev = atomicGet(&maxconc) {
+					atomicSet(&maxconc, conc)
+				}
+				time.Sleep(ms5_0)
+				return nil
+			})
+			time.Sleep(ms30_0)
+			assert.Equal(t, tcase.err, r.Result())
+			assert.LessOrEqual(t, tcase.min, atomicGet(&calls))
+			assert.GreaterOrEqual(t, tcase.max, atomicGet(&calls))
+			if tcase.err == nil {
+				if tcase.maxconc > 1 {
+					assert.LessOrEqual(t, tcase.maxconc, atomicGet(&maxconc))
+				} else {
+					assert.Equal(t, tcase.maxconc, atomicGet(&maxconc))
+				}
+			}
+		})
+	}
+}
+
+func TestRunnerScheduledErrors(t *testing.T) {
+	size := DefaultScheduledErrors
+	DefaultScheduledErrors = 2
+	defer func() { DefaultScheduledErrors = size }()
+	testerr := errors.New("test")
+	r := NewRunnerScheduled(
+		WithPolicy(context.Background(), PolicyContinue),
+		NewThrottlerEcho(nil),
+		NewScheduleEvery(ms1_0),
+		OverlapConcurrent,
+		0,
+	)
+	var calls uint64
+	r.Run(func(context.Context) error {
+		atomicIncr(&calls)
+		return testerr
+	})
+	time.Sleep(ms30_0)
+	err := r.Result()
+	errs, ok := err.(MultiError)
+	// only the most recent errors are kept
+	if !assert.True(t, ok) || !assert.Len(t, errs, 2) {
+		return
+	}
+	assert.Less(t, uint64(2), atomicGet(&calls))
+	assert.Less(t, errs[0].Index, errs[1].Index)
+	assert.Equal(t, fmt.Errorf("runnable error has happened %w", testerr), errs[1].Err)
+}
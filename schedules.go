@@ -0,0 +1,143 @@
+package gohalt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule defines scheduled runner schedule
+// that returns the next run timestamp strictly after the provided timestamp.
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+type schevery time.Duration
+
+// NewScheduleEvery creates fixed interval schedule instance
+// that runs each interval defined by the specified interval.
+func NewScheduleEvery(interval time.Duration) Schedule {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return schevery(interval)
+}
+
+func (sch schevery) Next(ts time.Time) time.Time {
+	return ts.Add(time.Duration(sch))
+}
+
+type schcron struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// day matches either dom or dow if both are restricted,
+	// only bare `*` is unrestricted, so steps like `*/n` are restricted
+	either bool
+}
+
+// NewScheduleCron creates cron schedule instance from the provided standard five fields
+// cron expression `minute hour day-of-month month day-of-week` in local time
+// or returns error if the expression is invalid.
+// Each field supports `*`, values, ranges `a-b`, steps `*/n` or `a-b/n` and lists `a,b`.
+func NewScheduleCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression is invalid %q", expr)
+	}
+	bounds := [5][2]uint64{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression is invalid %q %w", expr, err)
+		}
+		sets[i] = set
+	}
+	// sunday could be defined both as 0 and 7
+	if sets[4]&(1<<7) > 0 {
+		sets[4] |= 1
+	}
+	return schcron{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		either: restricted(fields[2]) && restricted(fields[4]),
+	}, nil
+}
+
+func (sch schcron) Next(ts time.Time) time.Time {
+	ts = ts.Truncate(time.Minute).Add(time.Minute)
+	// give up on impossible expressions like `0 0 31 2 *`
+	limit := ts.AddDate(5, 0, 0)
+	for ts.Before(limit) {
+		switch {
+		case sch.month&(1<<uint(ts.Month())) == 0:
+			ts = time.Date(ts.Year(), ts.Month()+1, 1, 0, 0, 0, 0, ts.Location())
+		case !sch.day(ts):
+			ts = time.Date(ts.Year(), ts.Month(), ts.Day()+1, 0, 0, 0, 0, ts.Location())
+		case sch.hour&(1<<uint(ts.Hour())) == 0:
+			ts = time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour()+1, 0, 0, 0, ts.Location())
+		case sch.minute&(1<<uint(ts.Minute())) == 0:
+			ts = ts.Add(time.Minute)
+		default:
+			return ts
+		}
+	}
+	return time.Time{}
+}
+
+func (sch schcron) day(ts time.Time) bool {
+	dom := sch.dom&(1<<uint(ts.Day())) > 0
+	dow := sch.dow&(1<<uint(ts.Weekday())) > 0
+	if sch.either {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func restricted(field string) bool {
+	return field != "*"
+}
+
+func parseCronField(field string, min uint64, max uint64) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, uint64(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			val, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || val == 0 {
+				return 0, fmt.Errorf("cron field step is invalid %q", part)
+			}
+			rng, step = part[:i], val
+		}
+		from, to := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			val, err := strconv.ParseUint(bounds[0], 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("cron field value is invalid %q", part)
+			}
+			from, to = val, val
+			if len(bounds) == 2 {
+				if to, err = strconv.ParseUint(bounds[1], 10, 8); err != nil {
+					return 0, fmt.Errorf("cron field value is invalid %q", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, fmt.Errorf("cron field value is out of range %q", part)
+		}
+		for val := from; val <= to; val += step {
+			set |= 1 << val
+		}
+	}
+	return set, nil
+}
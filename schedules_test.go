@@ -0,0 +1,102 @@
+package gohalt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedules(t *testing.T) {
+	ts := time.Date(2020, time.January, 31, 10, 30, 15, 0, time.UTC)
+	table := map[string]struct {
+		sch  Schedule
+		expr string
+		next time.Time
+		err  error
+	}{
+		"Every schedule should return timestamp after interval": {
+			sch:  NewScheduleEvery(ms10_0),
+			next: ts.Add(ms10_0),
+		},
+		"Cron schedule should return next minute on wildcards": {
+			expr: "* * * * *",
+			next: time.Date(2020, time.January, 31, 10, 31, 0, 0, time.UTC),
+		},
+		"Cron schedule should return next matching step": {
+			expr: "*/15 * * * *",
+			next: time.Date(2020, time.January, 31, 10, 45, 0, 0, time.UTC),
+		},
+		"Cron schedule should return next matching hour and minute": {
+			expr: "5 9-11 * * *",
+			next: time.Date(2020, time.January, 31, 11, 5, 0, 0, time.UTC),
+		},
+		"Cron schedule should return next matching month day": {
+			expr: "0 0 1,15 * *",
+			next: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"Cron schedule should return next matching week day": {
+			expr: "0 12 * * 7",
+			next: time.Date(2020, time.February, 2, 12, 0, 0, 0, time.UTC),
+		},
+		"Cron schedule should match either month day or week day": {
+			expr: "0 0 29 * 1",
+			next: time.Date(2020, time.February, 3, 0, 0, 0, 0, time.UTC),
+		},
+		"Cron schedule should treat month day step as restricted": {
+			expr: "0 0 */10 * 1",
+			next: time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"Cron schedule should return next matching leap day": {
+			expr: "0 0 29 2 *",
+			next: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		"Cron schedule should return zero timestamp on impossible expression": {
+			expr: "0 0 31 2 *",
+		},
+		"Cron schedule should return error on invalid fields number": {
+			expr: "* * * *",
+			err:  fmt.Errorf("cron expression is invalid %q", "* * * *"),
+		},
+		"Cron schedule should return error on out of range value": {
+			expr: "60 * * * *",
+			err: fmt.Errorf(
+				"cron expression is invalid %q %w",
+				"60 * * * *",
+				fmt.Errorf("cron field value is out of range %q", "60"),
+			),
+		},
+		"Cron schedule should return error on invalid step": {
+			expr: "*/0 * * * *",
+			err: fmt.Errorf(
+				"cron expression is invalid %q %w",
+				"*/0 * * * *",
+				fmt.Errorf("cron field step is invalid %q", "*/0"),
+			),
+		},
+		"Cron schedule should return error on invalid value": {
+			expr: "a * * * *",
+			err: fmt.Errorf(
+				"cron expression is invalid %q %w",
+				"a * * * *",
+				errors.New(`cron field value is invalid "a"`),
+			),
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			sch := tcase.sch
+			if sch == nil {
+				var err error
+				sch, err = NewScheduleCron(tcase.expr)
+				assert.Equal(t, tcase.err, err)
+				if err != nil {
+					return
+				}
+			}
+			assert.Equal(t, tcase.next, sch.Next(ts))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Switcher defines operational switch interface that returns the switch state.
+// Switch state is defined by one of the following strings:
+// - empty string or `pass` passes all calls.
+// - `reject` or `reject {{message}}` rejects all calls with optional custom error message.
+// - `pattern {{regexp}}` rejects only calls which key matches the regexp.
+type Switcher interface {
+	// State returns the switch state or internal error if any happened.
+	State(context.Context) (string, error)
+}
+
+// Flipper defines programmatic operational switch interface
+// that also allows to flip the switch state in runtime.
+type Flipper interface {
+	Switcher
+	// Flip sets the provided switch state or returns error if the state is invalid.
+	Flip(state string) error
+}
+
+// swts defines inner runnable type that returns switch state and possible error.
+type swts func(context.Context) (string, error)
+
+type swtfile struct {
+	swts  swts
+	state string
+}
+
+// NewSwitcherFile creates file switcher instance
+// with cache interval defined by the provided duration
+// which watches the provided file and uses its trimmed content as the switch state.
+// Missing file is treated as `pass` switch state.
+// Only successful file reads are cached, zero cache interval reads the file on each call.
+func NewSwitcherFile(path string, cache time.Duration) Switcher {
+	swt := &swtfile{}
+	memread := func(ctx context.Context) error {
+		return swt.read(ctx, path)
+	}
+	// zero cache never expires so it can't be used for reload
+	if cache > 0 {
+		memread, _ = cached(cache, memread)
+	}
+	var lock sync.Mutex
+	swt.swts = func(ctx context.Context) (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if err := memread(ctx); err != nil {
+			return swt.state, err
+		}
+		return swt.state, nil
+	}
+	return swt
+}
+
+func (swt *swtfile) State(ctx context.Context) (string, error) {
+	return swt.swts(ctx)
+}
+
+func (swt *swtfile) read(_ context.Context, path string) error {
+	content, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		swt.state = ""
+	case err != nil:
+		return err
+	default:
+		swt.state = string(content)
+	}
+	return nil
+}
+
+type swtenv struct {
+	name string
+}
+
+// NewSwitcherEnv creates env switcher instance
+// which uses the provided env variable value as the switch state.
+// Missing env variable is treated as `pass` switch state.
+func NewSwitcherEnv(name string) Switcher {
+	return swtenv{name: name}
+}
+
+func (swt swtenv) State(context.Context) (string, error) {
+	return os.Getenv(swt.name), nil
+}
+
+type swtmanual struct {
+	state string
+	lock  sync.RWMutex
+}
+
+// NewSwitcherManual creates programmatic switcher instance
+// with the provided initial switch state that could be flipped in runtime.
+func NewSwitcherManual(state string) Flipper {
+	return &swtmanual{state: state}
+}
+
+func (swt *swtmanual) State(context.Context) (string, error) {
+	swt.lock.RLock()
+	defer swt.lock.RUnlock()
+	return swt.state, nil
+}
+
+func (swt *swtmanual) Flip(state string) error {
+	if _, err := parseSwitch(state); err != nil {
+		return err
+	}
+	swt.lock.Lock()
+	defer swt.lock.Unlock()
+	swt.state = state
+	return nil
+}
+
+type swtmock struct {
+	state string
+	err   error
+}
+
+func (swt swtmock) State(context.Context) (string, error) {
+	return swt.state, swt.err
+}
+
+// switchs defines parsed switch state.
+type switchs struct {
+	reject  error
+	pattern *regexp.Regexp
+}
+
+func parseSwitch(state string) (switchs, error) {
+	state = strings.TrimSpace(state)
+	mode, arg := state, ""
+	if i := strings.IndexAny(state, " \t"); i >= 0 {
+		mode, arg = state[:i], strings.TrimSpace(state[i+1:])
+	}
+	switch {
+	case mode == "" || mode == "pass":
+		return switchs{}, nil
+	case mode == "reject" && arg == "":
+		return switchs{reject: errors.New("throttler has been switched to reject")}, nil
+	case mode == "reject":
+		return switchs{reject: errors.New(arg)}, nil
+	case mode == "pattern" && arg != "":
+		pattern, err := regexp.Compile(arg)
+		if err != nil {
+			return switchs{}, fmt.Errorf("switch state pattern is invalid %w", err)
+		}
+		return switchs{pattern: pattern}, nil
+	default:
+		return switchs{}, fmt.Errorf("switch state is invalid %q", state)
+	}
+}
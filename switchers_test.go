@@ -0,0 +1,82 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitchers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gohalt")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "switch")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("reject\n"), 0600))
+	assert.NoError(t, os.Setenv("GOHALT_TEST_SWITCH", "pattern ^admin$"))
+	defer os.Unsetenv("GOHALT_TEST_SWITCH")
+	flipper := NewSwitcherManual("pass")
+	table := map[string]struct {
+		swt   Switcher
+		flip  string
+		state string
+		fail  bool
+	}{
+		"Switcher file should return file content": {
+			swt:   NewSwitcherFile(path, 0),
+			state: "reject\n",
+		},
+		"Switcher file should return pass on missing file": {
+			swt:   NewSwitcherFile(filepath.Join(dir, "missing"), 0),
+			state: "",
+		},
+		"Switcher env should return env variable value": {
+			swt:   NewSwitcherEnv("GOHALT_TEST_SWITCH"),
+			state: "pattern ^admin$",
+		},
+		"Switcher manual should return flipped state": {
+			swt:   flipper,
+			flip:  "reject maintenance",
+			state: "reject maintenance",
+		},
+		"Switcher manual should not flip to invalid state": {
+			swt:   NewSwitcherManual("pass"),
+			flip:  "pattern (",
+			state: "pass",
+			fail:  true,
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			if tcase.flip != "" {
+				err := tcase.swt.(Flipper).Flip(tcase.flip)
+				assert.Equal(t, tcase.fail, err != nil)
+			}
+			state, err := tcase.swt.State(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tcase.state, state)
+		})
+	}
+}
+
+func TestSwitcherFileReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gohalt")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "switch")
+	ctx := context.Background()
+	thr := NewThrottlerSwitch(NewSwitcherFile(path, 0))
+	assert.NoError(t, thr.Acquire(ctx))
+	// zero cache reads file on each call
+	assert.NoError(t, ioutil.WriteFile(path, []byte("reject"), 0600))
+	assert.Equal(t, errors.New("throttler has been switched to reject"), thr.Acquire(ctx))
+	// invalid state keeps the last valid state
+	assert.NoError(t, ioutil.WriteFile(path, []byte("patern x"), 0600))
+	assert.Equal(t, errors.New("throttler has been switched to reject"), thr.Acquire(ctx))
+	assert.NoError(t, ioutil.WriteFile(path, []byte("pass"), 0600))
+	assert.NoError(t, thr.Acquire(ctx))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"regexp"
@@ -21,6 +22,32 @@ type Throttler interface {
 	Release(context.Context) error
 }
 
+// Tunable defines optional throttler extension that exposes throttler live state in form of meta
+// and allows to tune throttler limits or reset throttler state on the fly.
+type Tunable interface {
+	// Meta returns throttler live state in form of key value meta.
+	Meta() map[string]interface{}
+	// Tune sets throttler limit defined by the provided param to the provided value
+	// or returns `TuneError` if the param isn't tunable or the value is invalid.
+	// Duration limits are expected to be set in nanoseconds.
+	Tune(param string, value float64) error
+	// Reset puts throttler internal state back to initial one.
+	Reset()
+}
+
+func untunable(param string) error {
+	return TuneError{Param: param}
+}
+
+func invalid(param string, value float64) error {
+	return TuneError{Param: param, Value: value, Invalid: true}
+}
+
+// valid returns whether the provided value is finite and fits into [min, max] range.
+func valid(value float64, min float64, max float64) bool {
+	return !math.IsInf(value, 0) && value >= min && value <= max
+}
+
 type tmock struct {
 	aerr error
 	rerr error
@@ -195,7 +222,7 @@ func NewThrottlerEach(threshold uint64) Throttler {
 }
 
 func (thr *teach) Acquire(context.Context) error {
-	if current := atomicIncr(&thr.current); current%thr.threshold == 0 {
+	if current := atomicIncr(&thr.current); current%atomicGet(&thr.threshold) == 0 {
 		return errors.New("throttler has reached periodic threshold")
 	}
 	return nil
@@ -205,6 +232,28 @@ func (thr *teach) Release(context.Context) error {
 	return nil
 }
 
+func (thr *teach) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"current":   atomicGet(&thr.current),
+		"threshold": atomicGet(&thr.threshold),
+	}
+}
+
+func (thr *teach) Tune(param string, value float64) error {
+	if param != "threshold" {
+		return untunable(param)
+	}
+	if !valid(value, 1, math.MaxUint64) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.threshold, uint64(value))
+	return nil
+}
+
+func (thr *teach) Reset() {
+	atomicSet(&thr.current, 0)
+}
+
 type tbefore struct {
 	current   uint64
 	threshold uint64
@@ -216,8 +265,8 @@ func NewThrottlerBefore(threshold uint64) Throttler {
 	return &tbefore{threshold: threshold}
 }
 
-func (thr *tbefore) Acquire(context.Context) error {
-	if current := atomicBIncr(&thr.current); current <= thr.threshold {
+func (thr *tbefore) Acquire(ctx context.Context) error {
+	if current := atomicBAdd(&thr.current, ctxCost(ctx)); current <= atomicGet(&thr.threshold) {
 		return errors.New("throttler has not reached threshold yet")
 	}
 	return nil
@@ -227,6 +276,28 @@ func (thr *tbefore) Release(context.Context) error {
 	return nil
 }
 
+func (thr *tbefore) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"current":   atomicGet(&thr.current),
+		"threshold": atomicGet(&thr.threshold),
+	}
+}
+
+func (thr *tbefore) Tune(param string, value float64) error {
+	if param != "threshold" {
+		return untunable(param)
+	}
+	if !valid(value, 0, math.MaxUint64) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.threshold, uint64(value))
+	return nil
+}
+
+func (thr *tbefore) Reset() {
+	atomicSet(&thr.current, 0)
+}
+
 type tafter struct {
 	current   uint64
 	threshold uint64
@@ -238,8 +309,8 @@ func NewThrottlerAfter(threshold uint64) Throttler {
 	return &tafter{threshold: threshold}
 }
 
-func (thr *tafter) Acquire(context.Context) error {
-	if current := atomicBIncr(&thr.current); current > thr.threshold {
+func (thr *tafter) Acquire(ctx context.Context) error {
+	if current := atomicBAdd(&thr.current, ctxCost(ctx)); current > atomicGet(&thr.threshold) {
 		return errors.New("throttler has exceed threshold")
 	}
 	return nil
@@ -249,8 +320,30 @@ func (thr *tafter) Release(context.Context) error {
 	return nil
 }
 
+func (thr *tafter) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"current":   atomicGet(&thr.current),
+		"threshold": atomicGet(&thr.threshold),
+	}
+}
+
+func (thr *tafter) Tune(param string, value float64) error {
+	if param != "threshold" {
+		return untunable(param)
+	}
+	if !valid(value, 0, math.MaxUint64) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.threshold, uint64(value))
+	return nil
+}
+
+func (thr *tafter) Reset() {
+	atomicSet(&thr.current, 0)
+}
+
 type tchance struct {
-	threshold float64
+	threshold uint64
 }
 
 // NewThrottlerChance creates new throttler instance that
@@ -262,20 +355,40 @@ func NewThrottlerChance(threshold float64) Throttler {
 	if threshold > 1.0 {
 		threshold = 1.0
 	}
-	return tchance{threshold: threshold}
+	return &tchance{threshold: math.Float64bits(threshold)}
 }
 
-func (thr tchance) Acquire(context.Context) error {
-	if thr.threshold > 1.0-rand.Float64() {
+func (thr *tchance) Acquire(context.Context) error {
+	if threshold := math.Float64frombits(atomicGet(&thr.threshold)); threshold > 1.0-rand.Float64() {
 		return errors.New("throttler has reached chance threshold")
 	}
 	return nil
 }
 
-func (thr tchance) Release(context.Context) error {
+func (thr *tchance) Release(context.Context) error {
 	return nil
 }
 
+func (thr *tchance) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"threshold": math.Float64frombits(atomicGet(&thr.threshold)),
+	}
+}
+
+func (thr *tchance) Tune(param string, value float64) error {
+	if param != "threshold" {
+		return untunable(param)
+	}
+	if !valid(value, 0.0, 1.0) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.threshold, math.Float64bits(value))
+	return nil
+}
+
+func (thr *tchance) Reset() {
+}
+
 type trunning struct {
 	running   uint64
 	threshold uint64
@@ -288,91 +401,466 @@ func NewThrottlerRunning(threshold uint64) Throttler {
 	return &trunning{threshold: threshold}
 }
 
-func (thr *trunning) Acquire(context.Context) error {
-	if running := atomicBIncr(&thr.running); running > thr.threshold {
+func (thr *trunning) Acquire(ctx context.Context) error {
+	if running := atomicBAdd(&thr.running, ctxCost(ctx)); running > atomicGet(&thr.threshold) {
 		return errors.New("throttler has exceed running threshold")
 	}
 	return nil
 }
 
-func (thr *trunning) Release(context.Context) error {
-	atomicBDecr(&thr.running)
+func (thr *trunning) Release(ctx context.Context) error {
+	atomicBSub(&thr.running, ctxCost(ctx))
 	return nil
 }
 
+func (thr *trunning) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"running":   atomicGet(&thr.running),
+		"threshold": atomicGet(&thr.threshold),
+	}
+}
+
+func (thr *trunning) Tune(param string, value float64) error {
+	if param != "threshold" {
+		return untunable(param)
+	}
+	if !valid(value, 0, math.MaxUint64) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.threshold, uint64(value))
+	return nil
+}
+
+func (thr *trunning) Reset() {
+	atomicSet(&thr.running, 0)
+}
+
+// asyncer defines optional waiting throttler extension
+// that enqueues the call without blocking and returns channel with acquire result.
+type asyncer interface {
+	acquireAsync(context.Context) <-chan error
+}
+
+// AcquireAsync takes a part of throttling quota from the provided throttler
+// same way as `Acquire` does, but returns immediately with channel
+// that receives acquire result once the call is either admitted or throttled.
+// Waiting throttlers like `buffered` and `priority` enqueue the call without parking the caller goroutine,
+// if the context is done before the call is admitted, the call is removed from the queue right away
+// and receives context error, running quota granted together with the context being done
+// is released back if it hasn't been received yet;
+// all other throttlers are acquired inside new goroutine.
+// For waiting throttlers release needs to be called only for admitted calls.
+func AcquireAsync(ctx context.Context, thr Throttler) <-chan error {
+	if thr, ok := thr.(asyncer); ok {
+		return thr.acquireAsync(ctx)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- thr.Acquire(ctx)
+	}()
+	return done
+}
+
+// Discipline defines waiting throttler queue discipline
+// that picks which waiting call is admitted next once the running quota is available again.
+type Discipline uint8
+
+const (
+	// DisciplineFIFO admits the longest waiting call first.
+	DisciplineFIFO Discipline = iota
+	// DisciplineLIFO admits the most recent waiting call first.
+	DisciplineLIFO
+	// DisciplineAdaptiveLIFO admits waiting calls in FIFO order
+	// but switches to LIFO order under overload
+	// when the number of waiting calls reaches the running threshold.
+	DisciplineAdaptiveLIFO
+	// DisciplineEDF admits waiting call with the earliest context deadline first,
+	// calls without deadline are admitted after all calls with deadline in FIFO order.
+	DisciplineEDF
+)
+
+func (d Discipline) String() string {
+	switch d {
+	case DisciplineLIFO:
+		return "lifo"
+	case DisciplineAdaptiveLIFO:
+		return "adaptive lifo"
+	case DisciplineEDF:
+		return "edf"
+	default:
+		return "fifo"
+	}
+}
+
 type tbuffered struct {
-	running chan struct{}
+	running    uint64
+	threshold  uint64
+	discipline Discipline
+	waiters    []*waiter
+	lock       sync.Mutex
+}
+
+// waiter defines enqueued call waiting for the running quota.
+type waiter struct {
+	ctx        context.Context
+	deadline   time.Time
+	ts         time.Time
+	level      uint64
+	done       chan error
+	dispatched chan struct{}
+}
+
+func newWaiter(ctx context.Context) *waiter {
+	deadline, _ := ctx.Deadline()
+	return &waiter{
+		ctx:        ctx,
+		deadline:   deadline,
+		ts:         time.Now().UTC(),
+		done:       make(chan error, 1),
+		dispatched: make(chan struct{}),
+	}
+}
+
+// dispatch sends the provided acquire result to the waiter that has left the queue.
+func (w *waiter) dispatch(err error) {
+	w.done <- err
+	close(w.dispatched)
+}
+
+// watch waits until the waiter either leaves the queue or its context is done,
+// waiter which context is done is removed from the queue right away with context error,
+// running quota granted together with the context being done is revoked and released back
+// if it hasn't been received yet, as the caller could have already stopped waiting for it.
+func (w *waiter) watch(remove func(*waiter) bool, release Runnable) {
+	select {
+	case <-w.ctx.Done():
+		if remove(w) {
+			w.dispatch(w.ctx.Err())
+			return
+		}
+		<-w.dispatched
+	case <-w.dispatched:
+		if w.ctx.Err() == nil {
+			return
+		}
+	}
+	select {
+	case err := <-w.done:
+		if err == nil {
+			_ = release(w.ctx)
+			err = w.ctx.Err()
+		}
+		w.done <- err
+	default:
+	}
+}
+
+// remove removes the provided waiter from the provided waiters if it's still there.
+func remove(waiters []*waiter, w *waiter) ([]*waiter, bool) {
+	for i, qw := range waiters {
+		if qw == w {
+			return unqueue(waiters, i), true
+		}
+	}
+	return waiters, false
+}
+
+// err returns waiter context error
+// or deadline error if waiter deadline has already passed.
+func (w *waiter) err() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if !w.deadline.IsZero() && !time.Now().Before(w.deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// pick returns index of the next waiter to admit accordingly to the queue discipline,
+// overloaded defines whether adaptive LIFO discipline should switch to LIFO order.
+func (d Discipline) pick(waiters []*waiter, overloaded bool) int {
+	index := 0
+	switch d {
+	case DisciplineLIFO:
+		index = len(waiters) - 1
+	case DisciplineAdaptiveLIFO:
+		if overloaded {
+			index = len(waiters) - 1
+		}
+	case DisciplineEDF:
+		for i, w := range waiters {
+			if w.deadline.IsZero() {
+				continue
+			}
+			if earliest := waiters[index].deadline; earliest.IsZero() || w.deadline.Before(earliest) {
+				index = i
+			}
+		}
+	}
+	return index
+}
+
+// unqueue removes waiter with the provided index from the provided waiters.
+func unqueue(waiters []*waiter, index int) []*waiter {
+	if index == 0 {
+		waiters[0] = nil
+		return waiters[1:]
+	}
+	copy(waiters[index:], waiters[index+1:])
+	waiters[len(waiters)-1] = nil
+	return waiters[:len(waiters)-1]
 }
 
 // NewThrottlerBuffered creates new throttler instance that
 // waits on call which exeeds the running quota acquired - release
 // q defined by the specified threshold until the running quota is available again.
+// Waiting calls are admitted in FIFO order.
+// Waiting calls which context is done are skipped and receive context error.
+// Use `AcquireAsync` to wait for the running quota without blocking.
 func NewThrottlerBuffered(threshold uint64) Throttler {
-	return &tbuffered{running: make(chan struct{}, threshold)}
+	return NewThrottlerQueue(threshold, DisciplineFIFO)
 }
 
-func (thr *tbuffered) Acquire(context.Context) error {
-	thr.running <- struct{}{}
-	return nil
+// NewThrottlerQueue creates new throttler instance that
+// waits on call which exeeds the running quota acquired - release
+// q defined by the specified threshold until the running quota is available again.
+// Waiting calls are admitted in order defined by the specified queue discipline.
+// Waiting calls which context is done are skipped and receive context error.
+// Use `AcquireAsync` to wait for the running quota without blocking.
+func NewThrottlerQueue(threshold uint64, discipline Discipline) Throttler {
+	return &tbuffered{threshold: threshold, discipline: discipline}
+}
+
+func (thr *tbuffered) Acquire(ctx context.Context) error {
+	return <-thr.enqueue(ctx)
 }
 
 func (thr *tbuffered) Release(ctx context.Context) error {
-	select {
-	case <-thr.running:
-		return nil
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	if thr.running > 0 {
+		thr.running--
+	}
+	for len(thr.waiters) > 0 && thr.running < thr.threshold {
+		index := thr.discipline.pick(thr.waiters, uint64(len(thr.waiters)) >= thr.threshold)
+		w := thr.waiters[index]
+		thr.waiters = unqueue(thr.waiters, index)
+		// skip waiters which are already gone
+		if err := w.err(); err != nil {
+			w.dispatch(err)
+			continue
+		}
+		thr.running++
+		w.dispatch(nil)
+	}
+	return nil
+}
+
+func (thr *tbuffered) acquireAsync(ctx context.Context) <-chan error {
+	return thr.enqueue(ctx)
+}
+
+func (thr *tbuffered) enqueue(ctx context.Context) <-chan error {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	w := newWaiter(ctx)
+	switch err := w.err(); {
+	case err != nil:
+		w.done <- err
+	case len(thr.waiters) == 0 && thr.running < thr.threshold:
+		thr.running++
+		w.done <- nil
 	default:
-		return nil
+		thr.waiters = append(thr.waiters, w)
+		if ctx.Done() != nil {
+			go w.watch(thr.remove, thr.Release)
+		}
 	}
+	return w.done
 }
 
+func (thr *tbuffered) remove(w *waiter) (removed bool) {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	thr.waiters, removed = remove(thr.waiters, w)
+	return
+}
+
+// DefaultPriorityAging defines default waiting duration for `priority` throttler
+// after which waiting call priority weight is increased by one level.
+// By default DefaultPriorityAging is set to use `100 * time.Millisecond`.
+var DefaultPriorityAging = 100 * time.Millisecond
+
 type tpriority struct {
-	running   *sync.Map
-	threshold uint64
-	levels    uint8
+	running    uint64
+	threshold  uint64
+	levels     uint64
+	aging      time.Duration
+	discipline Discipline
+	queues     map[uint64][]*waiter
+	credits    map[uint64]int64
+	lock       sync.Mutex
 }
 
 // NewThrottlerPriority creates new throttler instance that
 // waits on call which exeeds the running quota acquired - release
 // q defined by the specified threshold until the running quota is available again.
-// Running quota is not equally distributed between n levels of priority
-// defined by the specified levels.
+// Running quota is shared between n levels of priority defined by the specified levels,
+// so any level could borrow idle running quota, but once running quota is available again
+// waiting calls are admitted with weighted priority scheduling where each level weight equals to the level.
+// Waiting call weight grows by one level each `DefaultPriorityAging` duration, so starving calls age upward.
+// Waiting calls of the same level are admitted in FIFO order.
+// Waiting calls which context is done are skipped and receive context error.
+// Levels number is limited by `math.MaxUint32`, only levels with waiting calls take memory.
 // Use `WithPriority` to override context call priority, 1 by default.
-func NewThrottlerPriority(threshold uint64, levels uint8) Throttler {
-	if levels == 0 {
+// Use `AcquireAsync` to wait for the running quota without blocking.
+func NewThrottlerPriority(threshold uint64, levels uint64) Throttler {
+	return NewThrottlerPriorityQueue(threshold, levels, DisciplineFIFO)
+}
+
+// NewThrottlerPriorityQueue creates new throttler instance that
+// works same way as `priority` throttler does, but waiting calls of the same level
+// are admitted in order defined by the specified queue discipline.
+// Adaptive LIFO discipline switches to LIFO order once the number of all waiting calls reaches the threshold.
+func NewThrottlerPriorityQueue(threshold uint64, levels uint64, discipline Discipline) Throttler {
+	switch {
+	case levels == 0:
 		levels = 1
+	case levels > math.MaxUint32:
+		levels = math.MaxUint32
 	}
-	running := &sync.Map{}
-	koef := float64(threshold) / (float64(levels) / 2 * float64((2 + (levels - 1))))
-	for i := uint8(1); i <= levels; i++ {
-		slots := uint64(math.Round(float64(i) * koef))
-		running.Store(i, make(chan struct{}, slots))
+	return &tpriority{
+		threshold:  threshold,
+		levels:     levels,
+		aging:      DefaultPriorityAging,
+		discipline: discipline,
+		queues:     make(map[uint64][]*waiter),
+		credits:    make(map[uint64]int64),
 	}
-	return tpriority{running: running, threshold: threshold, levels: levels}
 }
 
-func (thr tpriority) Acquire(ctx context.Context) error {
-	priority := ctxPriority(ctx, thr.levels)
-	val, _ := thr.running.Load(priority)
-	running := val.(chan struct{})
-	running <- struct{}{}
+func (thr *tpriority) Acquire(ctx context.Context) error {
+	return <-thr.enqueue(ctx)
+}
+
+func (thr *tpriority) Release(context.Context) error {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	if thr.running > 0 {
+		thr.running--
+	}
+	for thr.running < thr.threshold {
+		level, ok := thr.schedule()
+		if !ok {
+			break
+		}
+		queue := thr.queues[level]
+		index := thr.discipline.pick(queue, uint64(thr.waiting()) >= thr.threshold)
+		w := queue[index]
+		thr.update(level, unqueue(queue, index))
+		// skip waiters which are already gone
+		if err := w.err(); err != nil {
+			w.dispatch(err)
+			continue
+		}
+		thr.running++
+		w.dispatch(nil)
+	}
 	return nil
 }
 
-func (thr tpriority) Release(ctx context.Context) error {
+func (thr *tpriority) acquireAsync(ctx context.Context) <-chan error {
+	return thr.enqueue(ctx)
+}
+
+func (thr *tpriority) enqueue(ctx context.Context) <-chan error {
 	priority := ctxPriority(ctx, thr.levels)
-	val, _ := thr.running.Load(priority)
-	running := val.(chan struct{})
-	select {
-	case <-running:
-		return nil
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	w := newWaiter(ctx)
+	w.level = priority
+	switch err := w.err(); {
+	case err != nil:
+		w.done <- err
+	case thr.waiting() == 0 && thr.running < thr.threshold:
+		thr.running++
+		w.done <- nil
 	default:
-		return nil
+		thr.queues[priority] = append(thr.queues[priority], w)
+		if ctx.Done() != nil {
+			go w.watch(thr.remove, thr.Release)
+		}
+	}
+	return w.done
+}
+
+func (thr *tpriority) remove(w *waiter) bool {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	queue, removed := remove(thr.queues[w.level], w)
+	if removed {
+		thr.update(w.level, queue)
 	}
+	return removed
+}
+
+// update replaces the provided level queue, empty level queues are dropped.
+func (thr *tpriority) update(level uint64, queue []*waiter) {
+	if len(queue) > 0 {
+		thr.queues[level] = queue
+		return
+	}
+	delete(thr.queues, level)
+}
+
+// schedule picks the next level to admit with smooth weighted round robin
+// across all levels that have waiting calls, ties are won by higher level.
+func (thr *tpriority) schedule() (uint64, bool) {
+	// levels without waiting calls start from scratch
+	for level := range thr.credits {
+		if _, ok := thr.queues[level]; !ok {
+			delete(thr.credits, level)
+		}
+	}
+	var level uint64
+	var total int64
+	for l, queue := range thr.queues {
+		weight := int64(l)
+		// the longest waiting call is always the first one in the queue
+		if thr.aging > 0 {
+			weight += int64(time.Since(queue[0].ts) / thr.aging)
+		}
+		thr.credits[l] += weight
+		total += weight
+		if level == 0 || thr.credits[l] > thr.credits[level] || (thr.credits[l] == thr.credits[level] && l > level) {
+			level = l
+		}
+	}
+	if level == 0 {
+		return 0, false
+	}
+	thr.credits[level] -= total
+	return level, true
+}
+
+func (thr *tpriority) waiting() (waiting int) {
+	for _, queue := range thr.queues {
+		waiting += len(queue)
+	}
+	return
 }
 
 type ttimed struct {
 	*tafter
-	loop Runnable
+	tick     uint64
+	ticks    uint64
+	debt     uint64
+	loop     Runnable
+	interval time.Duration
+	quantum  time.Duration
+	window   time.Duration
+	delta    uint64
 }
 
 // NewThrottlerTimed creates new throttler instance that
@@ -380,6 +868,7 @@ type ttimed struct {
 // q defined by the specified threshold in the specified interval.
 // Periodically each specified interval the running quota number is reseted.
 // If quantum is set then quantum will be used instead of interval to provide the running quota delta updates.
+// Timed throttler implements `Reserver` to reserve the running quota ahead.
 func NewThrottlerTimed(threshold uint64, interval time.Duration, quantum time.Duration) Throttler {
 	tafter := NewThrottlerAfter(threshold).(*tafter)
 	delta, window := threshold, interval
@@ -387,35 +876,139 @@ func NewThrottlerTimed(threshold uint64, interval time.Duration, quantum time.Du
 		delta = uint64(math.Ceil(float64(threshold) / (float64(interval) / float64(quantum))))
 		window = quantum
 	}
-	thr := ttimed{tafter: tafter}
-	thr.loop = once(
-		loop(window, func(ctx context.Context) error {
-			atomicBSub(&thr.current, delta)
-			return ctx.Err()
-		}),
-	)
+	thr := &ttimed{tafter: tafter, interval: interval, quantum: quantum, window: window, delta: delta}
+	tick := loop(window, func(ctx context.Context) error {
+		atomicSet(&thr.tick, uint64(time.Now().UTC().UnixNano()))
+		atomicIncr(&thr.ticks)
+		atomicBSub(&thr.current, delta)
+		// reserved quota is paid first
+		atomicBSub(&thr.debt, delta)
+		return ctx.Err()
+	})
+	thr.loop = once(func(ctx context.Context) error {
+		atomicSet(&thr.tick, uint64(time.Now().UTC().UnixNano()))
+		return tick(ctx)
+	})
 	return thr
 }
 
-func (thr ttimed) Acquire(ctx context.Context) error {
+func (thr *ttimed) Acquire(ctx context.Context) error {
 	// start loop on first acquire
 	gorun(ctx, thr.loop)
 	err := thr.tafter.Acquire(ctx)
-	if current := atomicGet(&thr.current); current > thr.threshold {
-		atomicSet(&thr.current, thr.threshold)
+	// keep reserved quota on top of threshold
+	if threshold := atomicGet(&thr.threshold) + atomicGet(&thr.debt); atomicGet(&thr.current) > threshold {
+		atomicSet(&thr.current, threshold)
 	}
 	return err
 }
 
-func (thr ttimed) Release(ctx context.Context) error {
+func (thr *ttimed) Release(ctx context.Context) error {
 	_ = thr.tafter.Release(ctx)
 	return nil
 }
 
+func (thr *ttimed) hint(context.Context) time.Duration {
+	tick := atomicGet(&thr.tick)
+	// loop hasn't been started yet
+	if tick == 0 {
+		return thr.window
+	}
+	next := time.Unix(0, int64(tick)).Add(thr.window)
+	if wait := time.Until(next); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+func (thr *ttimed) Reserve(ctx context.Context, n uint64) (Reservation, error) {
+	threshold := atomicGet(&thr.threshold)
+	if n > threshold || thr.delta == 0 {
+		return nil, fmt.Errorf("throttler can't reserve %d above threshold", n)
+	}
+	// start loop on first reserve
+	gorun(ctx, thr.loop)
+	r := &reservation{thr: thr, n: n, ts: time.Now().UTC(), ticks: atomicGet(&thr.ticks)}
+	if current := atomicBAdd(&thr.current, n); current > threshold {
+		excess := current - threshold
+		r.debt = excess
+		if r.debt > n {
+			r.debt = n
+		}
+		// reserved quota debt is paid after all debt ahead of it
+		r.ahead = atomicBAdd(&thr.debt, r.debt) - r.debt
+		// reserved quota is available after enough running quota updates
+		windows := (excess + thr.delta - 1) / thr.delta
+		r.ts = r.ts.Add(thr.hint(ctx) + time.Duration(windows-1)*thr.window)
+	}
+	return r, nil
+}
+
+// Reserver defines optional rate throttler extension
+// that reserves the running quota for now or for later.
+type Reserver interface {
+	// Reserve reserves n units of the running quota and returns the reservation
+	// or returns error if the quota can't be ever reserved.
+	// Reserved quota is taken from the running quota immediately,
+	// so subsequent `Acquire` calls are throttled until the reserved quota is paid back.
+	Reserve(ctx context.Context, n uint64) (Reservation, error)
+}
+
+// Reservation defines reserved running quota.
+type Reservation interface {
+	// Delay returns duration left until the reserved quota is available.
+	Delay() time.Duration
+	// Cancel returns the reserved quota back if it isn't available yet,
+	// quota that has been already paid by elapsed running quota updates isn't returned.
+	Cancel()
+}
+
+type reservation struct {
+	thr      *ttimed
+	n        uint64
+	debt     uint64
+	ahead    uint64
+	ticks    uint64
+	ts       time.Time
+	canceled uint64
+}
+
+func (r *reservation) Delay() time.Duration {
+	if delay := time.Until(r.ts); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+func (r *reservation) Cancel() {
+	// available quota is considered to be used
+	if r.Delay() == 0 || atomicBIncr(&r.canceled) > 1 {
+		return
+	}
+	// reserved quota of the current window is returned back
+	// only until the window is over
+	ticks := atomicGet(&r.thr.ticks) - r.ticks
+	if ticks == 0 {
+		atomicBSub(&r.thr.current, r.n)
+		atomicBSub(&r.thr.debt, r.debt)
+		return
+	}
+	// otherwise only not yet paid reserved quota debt is returned back
+	var paid uint64
+	if total := ticks * r.thr.delta; total > r.ahead {
+		paid = total - r.ahead
+	}
+	if paid >= r.debt {
+		return
+	}
+	atomicBSub(&r.thr.current, r.debt-paid)
+	atomicBSub(&r.thr.debt, r.debt-paid)
+}
+
 type tlatency struct {
 	reset     Runnable
 	latency   uint64
-	threshold time.Duration
+	threshold uint64
 }
 
 // NewThrottlerLatency creates new throttler instance that
@@ -423,7 +1016,7 @@ type tlatency struct {
 // If retention is set then throttler state will be reseted after retention duration.
 // Use `WithTimestamp` to specify running duration between throttler acquire and release.
 func NewThrottlerLatency(threshold time.Duration, retention time.Duration) Throttler {
-	thr := &tlatency{threshold: threshold}
+	thr := &tlatency{threshold: uint64(threshold)}
 	thr.reset = delayed(retention, func(context.Context) error {
 		atomicSet(&thr.latency, 0)
 		return nil
@@ -432,7 +1025,7 @@ func NewThrottlerLatency(threshold time.Duration, retention time.Duration) Throt
 }
 
 func (thr *tlatency) Acquire(context.Context) error {
-	if latency := atomicGet(&thr.latency); latency > uint64(thr.threshold) {
+	if latency := atomicGet(&thr.latency); latency > atomicGet(&thr.threshold) {
 		return errors.New("throttler has exceed latency threshold")
 	}
 	return nil
@@ -442,18 +1035,40 @@ func (thr *tlatency) Release(ctx context.Context) error {
 	nowTs := time.Now().UTC().UnixNano()
 	ctxTs := ctxTimestamp(ctx).UnixNano()
 	latency := uint64(nowTs - ctxTs)
-	if latency >= uint64(thr.threshold) && atomicGet(&thr.latency) == 0 {
+	if latency >= atomicGet(&thr.threshold) && atomicGet(&thr.latency) == 0 {
 		atomicSet(&thr.latency, latency)
 		gorun(ctx, thr.reset)
 	}
 	return nil
 }
 
+func (thr *tlatency) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"latency":   time.Duration(atomicGet(&thr.latency)),
+		"threshold": time.Duration(atomicGet(&thr.threshold)),
+	}
+}
+
+func (thr *tlatency) Tune(param string, value float64) error {
+	if param != "threshold" {
+		return untunable(param)
+	}
+	if !valid(value, 0, math.MaxUint64) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.threshold, uint64(value))
+	return nil
+}
+
+func (thr *tlatency) Reset() {
+	atomicSet(&thr.latency, 0)
+}
+
 type tpercentile struct {
 	reset      Runnable
 	latencies  *percentiles
-	threshold  time.Duration
-	percentile float64
+	threshold  uint64
+	percentile uint64
 }
 
 // NewThrottlerPercentile creates new throttler instance that
@@ -472,7 +1087,7 @@ func NewThrottlerPercentile(
 	if percentile > 1.0 {
 		percentile = 1.0
 	}
-	thr := tpercentile{threshold: threshold, percentile: percentile}
+	thr := &tpercentile{threshold: uint64(threshold), percentile: math.Float64bits(percentile)}
 	thr.latencies = &percentiles{cap: capacity}
 	thr.latencies.Prune()
 	thr.reset = locked(
@@ -484,9 +1099,10 @@ func NewThrottlerPercentile(
 	return thr
 }
 
-func (thr tpercentile) Acquire(ctx context.Context) error {
+func (thr *tpercentile) Acquire(ctx context.Context) error {
 	if thr.latencies.Len() > 0 {
-		if latency := thr.latencies.At(thr.percentile); latency >= uint64(thr.threshold) {
+		percentile := math.Float64frombits(atomicGet(&thr.percentile))
+		if latency := thr.latencies.At(percentile); latency >= atomicGet(&thr.threshold) {
 			gorun(ctx, thr.reset)
 			return errors.New("throttler has exceed latency threshold")
 		}
@@ -494,7 +1110,7 @@ func (thr tpercentile) Acquire(ctx context.Context) error {
 	return nil
 }
 
-func (thr tpercentile) Release(ctx context.Context) error {
+func (thr *tpercentile) Release(ctx context.Context) error {
 	nowTs := time.Now().UTC().UnixNano()
 	ctxTs := ctxTimestamp(ctx).UnixNano()
 	latency := uint64(nowTs - ctxTs)
@@ -502,6 +1118,87 @@ func (thr tpercentile) Release(ctx context.Context) error {
 	return nil
 }
 
+func (thr *tpercentile) Meta() map[string]interface{} {
+	percentile := math.Float64frombits(atomicGet(&thr.percentile))
+	meta := map[string]interface{}{
+		"latencies":  thr.latencies.Len(),
+		"percentile": percentile,
+		"threshold":  time.Duration(atomicGet(&thr.threshold)),
+	}
+	if thr.latencies.Len() > 0 {
+		meta["latency"] = time.Duration(thr.latencies.At(percentile))
+	}
+	return meta
+}
+
+func (thr *tpercentile) Tune(param string, value float64) error {
+	switch {
+	case param == "threshold" && valid(value, 0, math.MaxUint64):
+		atomicSet(&thr.threshold, uint64(value))
+	case param == "percentile" && valid(value, 0.0, 1.0):
+		atomicSet(&thr.percentile, math.Float64bits(value))
+	case param == "threshold" || param == "percentile":
+		return invalid(param, value)
+	default:
+		return untunable(param)
+	}
+	return nil
+}
+
+func (thr *tpercentile) Reset() {
+	thr.latencies.Prune()
+}
+
+type tdeadline struct {
+	*tpercentile
+}
+
+// NewThrottlerDeadline creates new throttler instance that
+// throttles each call which context deadline is closer than the expected call latency l
+// defined by the specified percentile of previous call latencies,
+// so calls that would miss their deadline anyway are rejected before any work is done.
+// Calls without context deadline and calls before any latency is known are never throttled.
+// Percentile values are kept in bounded buffer with capacity c defined by the specified capacity.
+// Use `WithTimestamp` to specify running duration between throttler acquire and release,
+// only releases with timestamp of admitted calls are kept as latencies.
+func NewThrottlerDeadline(capacity uint8, percentile float64) Throttler {
+	return tdeadline{tpercentile: NewThrottlerPercentile(0, capacity, percentile, 0).(*tpercentile)}
+}
+
+func (thr tdeadline) Acquire(ctx context.Context) error {
+	deadline, ok := ctx.Deadline()
+	if !ok || thr.latencies.Len() == 0 {
+		return nil
+	}
+	percentile := math.Float64frombits(atomicGet(&thr.percentile))
+	latency := time.Duration(thr.latencies.At(percentile))
+	if time.Until(deadline) < latency {
+		return errors.New("throttler has predicted deadline miss")
+	}
+	return nil
+}
+
+func (thr tdeadline) Release(ctx context.Context) error {
+	// calls without timestamp don't tell anything about latency
+	if _, ok := ctx.Value(ghctxtimestamp).(time.Time); !ok {
+		return nil
+	}
+	return thr.tpercentile.Release(ctx)
+}
+
+func (thr tdeadline) Meta() map[string]interface{} {
+	meta := thr.tpercentile.Meta()
+	delete(meta, "threshold")
+	return meta
+}
+
+func (thr tdeadline) Tune(param string, value float64) error {
+	if param != "percentile" {
+		return untunable(param)
+	}
+	return thr.tpercentile.Tune(param, value)
+}
+
 type tmonitor struct {
 	mnt       Monitor
 	threshold Stats
@@ -601,7 +1298,7 @@ func (thr tenqueue) Release(ctx context.Context) error {
 }
 
 type tadaptive struct {
-	ttimed
+	*ttimed
 	step uint64
 	thr  Throttler
 }
@@ -615,6 +1312,7 @@ type tadaptive struct {
 // Provided adapted throttler adjusts the running quota of adapter throttler by changing the value by d
 // defined by the specified step, it subtracts *d^2* from the running quota
 // if adapted throttler throttles or adds *d* to the running quota if it doesn't.
+// Adaptive throttler implements `Reserver` to reserve the running quota ahead.
 func NewThrottlerAdaptive(
 	threshold uint64,
 	interval time.Duration,
@@ -623,7 +1321,7 @@ func NewThrottlerAdaptive(
 	thr Throttler,
 ) Throttler {
 	tadaptive := &tadaptive{step: step, thr: thr}
-	tadaptive.ttimed = NewThrottlerTimed(threshold, interval, quantum).(ttimed)
+	tadaptive.ttimed = NewThrottlerTimed(threshold, interval, quantum).(*ttimed)
 	return tadaptive
 }
 
@@ -837,10 +1535,79 @@ func (thr tretry) Release(ctx context.Context) error {
 	return nil
 }
 
+// hinter defines optional throttler extension
+// that returns duration after which throttled call could be admitted again.
+type hinter interface {
+	hint(context.Context) time.Duration
+}
+
+type tshaping struct {
+	thr     Throttler
+	initial time.Duration
+	limit   time.Duration
+}
+
+// NewThrottlerShaping creates new throttler instance that
+// waits instead of throttling until provided throttler admits the call or the context is done.
+// After each throttled acquire it waits either for retry after hint of provided throttler
+// if it has any, like `timed` and `adaptive` throttlers do, or for exponential backoff
+// starting from the specified initial duration up until the specified duration limit is reached.
+// Each throttled acquire is released before the next one, so running quota stays balanced.
+// Non positive initial duration is replaced with `DefaultRetriedDuration` to never spin.
+func NewThrottlerShaping(thr Throttler, initial time.Duration, limit time.Duration) Throttler {
+	if initial <= 0 {
+		initial = DefaultRetriedDuration
+	}
+	if limit < initial {
+		limit = initial
+	}
+	return tshaping{thr: thr, initial: initial, limit: limit}
+}
+
+func (thr tshaping) Acquire(ctx context.Context) error {
+	return thr.shape(ctx, thr.thr.Acquire, thr.thr.Release)
+}
+
+func (thr tshaping) Release(ctx context.Context) error {
+	_ = thr.thr.Release(ctx)
+	return nil
+}
+
+func (thr tshaping) shape(ctx context.Context, acquire Runnable, release Runnable) error {
+	backoff := thr.initial
+	for {
+		err := acquire(ctx)
+		if err == nil {
+			return nil
+		}
+		var wait time.Duration
+		if hinter, ok := thr.thr.(hinter); ok {
+			wait = hinter.hint(ctx)
+		}
+		// fallback to backoff without any hint
+		if wait == 0 {
+			wait = backoff
+			if backoff *= 2; backoff > thr.limit {
+				backoff = thr.limit
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			// the last throttled acquire is released by caller
+			return err
+		case <-timer.C:
+			_ = release(ctx)
+		}
+	}
+}
+
 type tcache struct {
 	thr     Throttler
 	acquire Runnable
 	reset   Runnable
+	cache   time.Duration
 }
 
 // NewThrottlerCache creates new throttler instance that
@@ -848,8 +1615,13 @@ type tcache struct {
 // throttler release resulting resets cache.
 // Only non throttling calls are cached for the provided cache duration.
 func NewThrottlerCache(thr Throttler, cache time.Duration) Throttler {
-	tcache := tcache{thr: thr}
+	tcache := tcache{thr: thr, cache: cache}
 	tcache.acquire, tcache.reset = cached(cache, func(ctx context.Context) error {
+		// record underlying throttler into ticket
+		// only if cached call was acquired with ticket
+		if t := ctxTicket(ctx); t != nil {
+			return t.acquire(ctx, thr)
+		}
 		return thr.Acquire(ctx)
 	})
 	return tcache
@@ -864,3 +1636,320 @@ func (thr tcache) Release(ctx context.Context) error {
 	_ = thr.reset(ctx)
 	return nil
 }
+
+// DefaultShadowTimeout defines default evaluation timeout of `shadow` throttler candidate,
+// candidate that hasn't decided within the timeout is considered to be throttling.
+// By default DefaultShadowTimeout is set to use `10 * time.Millisecond`.
+var DefaultShadowTimeout = 10 * time.Millisecond
+
+type tshadow struct {
+	thr        Throttler
+	candidate  Throttler
+	timeout    time.Duration
+	calls      uint64
+	throttled  uint64
+	admitted   uint64
+	samples    []string
+	capacity   uint8
+	candidates []*shadowcall
+	lock       sync.Mutex
+}
+
+// shadowcall defines single call candidate evaluation
+// that is released once both the evaluation and the call are finished.
+type shadowcall struct {
+	ctx      context.Context
+	ct       *ticket
+	finished uint64
+}
+
+// NewThrottlerShadow creates new throttler instance that
+// throttles only if provided enforced throttler throttles,
+// but also evaluates provided candidate throttler on each call without ever enforcing it.
+// Shadow throttler records calls where candidate throttler would have disagreed with enforced throttler
+// and keeps recent disagreed call keys in bounded buffer with capacity c defined by the specified capacity.
+// Candidate throttler is evaluated asynchronously, so it never delays enforced calls,
+// and the comparison is recorded once the candidate decides;
+// candidate that hasn't decided within `DefaultShadowTimeout` is considered to be throttling.
+// Candidate throttler is released once both its evaluation and the call are finished,
+// tickets and runners match each release to its own acquire, while plain releases are matched in order.
+// Use `WithKey` to specify key for sampled calls.
+// Use `Tunable` meta to inspect recorded disagreements.
+func NewThrottlerShadow(thr Throttler, candidate Throttler, capacity uint8) Throttler {
+	return &tshadow{thr: thr, candidate: candidate, timeout: DefaultShadowTimeout, capacity: capacity}
+}
+
+func (thr *tshadow) Acquire(ctx context.Context) error {
+	err := thr.thr.Acquire(ctx)
+	sc := thr.evaluate(ctx, err)
+	thr.lock.Lock()
+	thr.candidates = append(thr.candidates, sc)
+	thr.lock.Unlock()
+	return err
+}
+
+func (thr *tshadow) Release(ctx context.Context) error {
+	thr.lock.Lock()
+	var sc *shadowcall
+	if len(thr.candidates) > 0 {
+		sc = thr.candidates[0]
+		thr.candidates[0] = nil
+		thr.candidates = thr.candidates[1:]
+	}
+	thr.lock.Unlock()
+	if sc != nil {
+		thr.finish(sc)
+	}
+	return thr.thr.Release(ctx)
+}
+
+func (thr *tshadow) compare(ctx context.Context, err error, cerr error) {
+	atomicIncr(&thr.calls)
+	switch {
+	case err == nil && cerr != nil:
+		atomicIncr(&thr.throttled)
+	case err != nil && cerr == nil:
+		atomicIncr(&thr.admitted)
+	default:
+		return
+	}
+	log("shadow throttler disagreement happened %v %v", err, cerr)
+	thr.sample(ctxKey(ctx))
+}
+
+func (thr *tshadow) Meta() map[string]interface{} {
+	thr.lock.Lock()
+	samples := make([]string, len(thr.samples))
+	_ = copy(samples, thr.samples)
+	thr.lock.Unlock()
+	return map[string]interface{}{
+		"calls":     atomicGet(&thr.calls),
+		"throttled": atomicGet(&thr.throttled),
+		"admitted":  atomicGet(&thr.admitted),
+		"samples":   samples,
+	}
+}
+
+func (thr *tshadow) Tune(param string, value float64) error {
+	return untunable(param)
+}
+
+func (thr *tshadow) Reset() {
+	atomicSet(&thr.calls, 0)
+	atomicSet(&thr.throttled, 0)
+	atomicSet(&thr.admitted, 0)
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	thr.samples = nil
+}
+
+// evaluate asynchronously acquires candidate throttler within the evaluation timeout
+// and compares its decision with the provided enforced throttler error once it decides.
+func (thr *tshadow) evaluate(ctx context.Context, err error) *shadowcall {
+	// candidate evaluation could outlive the call context
+	sc := &shadowcall{ctx: withValues(ctx), ct: &ticket{}}
+	go func() {
+		cctx, cancel := context.WithTimeout(sc.ctx, thr.timeout)
+		defer cancel()
+		cerr := func() (err error) {
+			// candidate throttler should never affect enforced call
+			// so its panics are treated as throttling
+			defer func() {
+				if msg := recover(); msg != nil {
+					err = fmt.Errorf("throttler has recovered candidate panic %v", msg)
+				}
+			}()
+			return sc.ct.acquire(cctx, thr.candidate)
+		}()
+		if cerr == nil && cctx.Err() != nil {
+			cerr = fmt.Errorf("throttler candidate hasn't decided in time %w", cctx.Err())
+		}
+		thr.compare(sc.ctx, err, cerr)
+		thr.finish(sc)
+	}()
+	return sc
+}
+
+// finish releases candidate throttler once both the evaluation and the call are finished.
+func (thr *tshadow) finish(sc *shadowcall) {
+	if atomicBIncr(&sc.finished) < 2 {
+		return
+	}
+	// candidate throttler should never affect enforced call
+	// so its release panics are ignored
+	_ = catch(func() error { return sc.ct.Release(sc.ctx) })
+}
+
+func (thr *tshadow) sample(key string) {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	if thr.capacity == 0 {
+		return
+	}
+	if len(thr.samples) >= int(thr.capacity) {
+		thr.samples = thr.samples[1:]
+	}
+	thr.samples = append(thr.samples, key)
+}
+
+type tcanary struct {
+	thrs       [2]Throttler
+	percentage uint64
+	calls      [2]uint64
+	running    map[string]*[2]uint64
+	lock       sync.Mutex
+}
+
+// NewThrottlerCanary creates new throttler instance that
+// throttles if provided current throttler throttles for most of calls
+// and if provided candidate throttler throttles for the deterministic fraction of calls
+// defined by the specified percentage.
+// Percentage value is normalized to [0.0, 1.0] range.
+// Calls are split by hash of their key so calls with the same key always stick to the same throttler
+// and release always goes to the throttler that acquired.
+// Use `WithKey` to specify key for calls splitting.
+// Use `Tunable` percentage param to gradually grow candidate throttler share.
+func NewThrottlerCanary(thr Throttler, candidate Throttler, percentage float64) Throttler {
+	percentage = math.Abs(percentage)
+	if percentage > 1.0 {
+		percentage = 1.0
+	}
+	return &tcanary{
+		thrs:       [2]Throttler{thr, candidate},
+		percentage: math.Float64bits(percentage),
+		running:    make(map[string]*[2]uint64),
+	}
+}
+
+func (thr *tcanary) Acquire(ctx context.Context) error {
+	key := ctxKey(ctx)
+	index := thr.route(key)
+	thr.lock.Lock()
+	running, ok := thr.running[key]
+	if !ok {
+		running = &[2]uint64{}
+		thr.running[key] = running
+	}
+	running[index]++
+	thr.lock.Unlock()
+	atomicIncr(&thr.calls[index])
+	return thr.thrs[index].Acquire(ctx)
+}
+
+func (thr *tcanary) Release(ctx context.Context) error {
+	key := ctxKey(ctx)
+	index := thr.route(key)
+	thr.lock.Lock()
+	// prefer throttler that acquired the call with the same key
+	// even if the percentage has been changed in between
+	if running, ok := thr.running[key]; ok {
+		if running[index] == 0 {
+			index = 1 - index
+		}
+		if running[index] > 0 {
+			running[index]--
+		}
+		if running[0] == 0 && running[1] == 0 {
+			delete(thr.running, key)
+		}
+	}
+	thr.lock.Unlock()
+	return thr.thrs[index].Release(ctx)
+}
+
+func (thr *tcanary) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"percentage": math.Float64frombits(atomicGet(&thr.percentage)),
+		"current":    atomicGet(&thr.calls[0]),
+		"candidate":  atomicGet(&thr.calls[1]),
+	}
+}
+
+func (thr *tcanary) Tune(param string, value float64) error {
+	if param != "percentage" {
+		return untunable(param)
+	}
+	if !valid(value, 0.0, 1.0) {
+		return invalid(param, value)
+	}
+	atomicSet(&thr.percentage, math.Float64bits(value))
+	return nil
+}
+
+func (thr *tcanary) Reset() {
+	atomicSet(&thr.calls[0], 0)
+	atomicSet(&thr.calls[1], 0)
+}
+
+func (thr *tcanary) route(key string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	bucket := float64(hash.Sum32()) / float64(math.MaxUint32+1)
+	if bucket < math.Float64frombits(atomicGet(&thr.percentage)) {
+		return 1
+	}
+	return 0
+}
+
+type tswitch struct {
+	swt    Switcher
+	state  string
+	parsed *switchs
+	lock   sync.Mutex
+}
+
+// NewThrottlerSwitch creates new throttler instance that
+// throttles call accordingly to the state returned by provided operational switcher
+// or if any internal error occurred.
+// Switch state could either pass all calls, reject all calls with optional custom error
+// or reject only calls which key matches the regexp pattern, see `Switcher` for details.
+// Use builtin `NewSwitcherFile` to create watched file switcher instance,
+// `NewSwitcherEnv` to create env variable switcher instance
+// or `NewSwitcherManual` to create programmatic switcher instance.
+// Use `WithKey` to specify key for regexp pattern switch state matching.
+// Invalid switch state is logged and ignored, so the last valid switch state is used instead
+// or all calls are passed if no valid switch state has been seen yet.
+func NewThrottlerSwitch(swt Switcher) Throttler {
+	return &tswitch{swt: swt}
+}
+
+func (thr *tswitch) Acquire(ctx context.Context) error {
+	state, err := thr.swt.State(ctx)
+	if err != nil {
+		return fmt.Errorf("throttler hasn't found any switch state %w", err)
+	}
+	parsed := thr.parse(state)
+	if parsed.reject != nil {
+		return parsed.reject
+	}
+	if parsed.pattern != nil && parsed.pattern.MatchString(ctxKey(ctx)) {
+		return errors.New("throttler has been switched to reject key")
+	}
+	return nil
+}
+
+func (thr *tswitch) Release(context.Context) error {
+	return nil
+}
+
+func (thr *tswitch) parse(state string) switchs {
+	thr.lock.Lock()
+	defer thr.lock.Unlock()
+	// reuse last parsed state
+	// to avoid regexp recompilation on each call
+	if thr.parsed != nil && state == thr.state {
+		return *thr.parsed
+	}
+	parsed, err := parseSwitch(state)
+	if err != nil {
+		// typo in switch state shouldn't turn into full outage
+		// so keep the last valid switch state or fail open
+		log("throttler switch state is ignored %v", err)
+		if thr.parsed != nil {
+			return *thr.parsed
+		}
+		return switchs{}
+	}
+	thr.state, thr.parsed = state, &parsed
+	return parsed
+}
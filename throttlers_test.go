@@ -246,6 +246,20 @@ func TestThrottlers(t *testing.T) {
 				errors.New("throttler has exceed threshold"),
 			},
 		},
+		"Throttler after should throttle after threshold with call cost": {
+			tms: 3,
+			thr: NewThrottlerAfter(3),
+			ctxs: []context.Context{
+				WithCost(context.Background(), 2),
+				WithCost(context.Background(), 1),
+				WithCost(context.Background(), 2),
+			},
+			errs: []error{
+				nil,
+				nil,
+				errors.New("throttler has exceed threshold"),
+			},
+		},
 		"Throttler chance should throttle on 1": {
 			tms: 3,
 			thr: NewThrottlerChance(1),
@@ -283,6 +297,51 @@ func TestThrottlers(t *testing.T) {
 			},
 			over: true,
 		},
+		"Throttler running should throttle on threshold with call cost": {
+			tms: 3,
+			thr: NewThrottlerRunning(2),
+			ctxs: []context.Context{
+				WithCost(context.Background(), 2),
+				WithCost(context.Background(), 2),
+				WithCost(context.Background(), 2),
+			},
+			acts: []Runnable{
+				delayed(ms1_0, nope),
+				delayed(ms1_0, nope),
+				delayed(ms1_0, nope),
+			},
+			errs: []error{
+				nil,
+				errors.New("throttler has exceed running threshold"),
+				errors.New("throttler has exceed running threshold"),
+			},
+			over: true,
+		},
+		"Throttler lease should throttle on threshold": {
+			tms: 3,
+			thr: NewThrottlerLease(1, time.Minute),
+			acts: []Runnable{
+				delayed(ms1_0, nope),
+				delayed(ms1_0, nope),
+				delayed(ms1_0, nope),
+			},
+			errs: []error{
+				nil,
+				errors.New("throttler has exceed running threshold"),
+				errors.New("throttler has exceed running threshold"),
+			},
+			over: true,
+		},
+		"Throttler lease should not throttle on expired leases": {
+			tms: 3,
+			thr: NewThrottlerLease(1, ms1_0),
+			ins: []Runnable{
+				delayed(ms2_0, nope),
+				delayed(ms2_0, nope),
+				delayed(ms2_0, nope),
+			},
+			pass: true,
+		},
 		"Throttler buffered should throttle on threshold": {
 			tms: 3,
 			thr: NewThrottlerBuffered(1),
@@ -337,27 +396,28 @@ func TestThrottlers(t *testing.T) {
 			durs: []time.Duration{
 				0,
 				0,
-				ms2_0,
 				0,
 				0,
 				0,
 				ms2_0,
+				ms2_0,
 			},
 		},
 		"Throttler timed should throttle after threshold": {
 			tms: 6,
 			thr: NewThrottlerTimed(
 				2,
-				ms2_0,
+				ms30_0,
 				ms0_0,
 			),
+			// delays are in the middle of interval to tolerate timers lag
 			pres: []Runnable{
 				nil,
 				nil,
 				nil,
 				nil,
-				delayed(ms3_0, nope),
-				delayed(ms3_0, nope),
+				delayed(ms30_0+ms10_0+ms5_0, nope),
+				delayed(ms30_0+ms10_0+ms5_0, nope),
 			},
 			errs: []error{
 				nil,
@@ -372,16 +432,17 @@ func TestThrottlers(t *testing.T) {
 			tms: 6,
 			thr: NewThrottlerTimed(
 				2,
-				ms8_0,
-				ms4_0,
+				ms30_0+ms30_0,
+				ms30_0,
 			),
+			// delays are in the middle of quantums to tolerate timers lag
 			pres: []Runnable{
 				nil,
 				nil,
 				nil,
-				delayed(ms5_0, nope),
+				delayed(ms30_0+ms10_0+ms5_0, nope),
 				nil,
-				delayed(ms10_0, nope),
+				delayed(ms30_0+ms30_0+ms10_0+ms5_0, nope),
 			},
 			errs: []error{
 				nil,
@@ -483,6 +544,24 @@ func TestThrottlers(t *testing.T) {
 				errors.New("throttler has exceed latency threshold"),
 			},
 		},
+		"Throttler deadline should not throttle calls without deadline": {
+			tms: 3,
+			thr: NewThrottlerDeadline(10, 0.5),
+			tss: []time.Duration{
+				-ms5_0,
+				-ms5_0,
+				-ms5_0,
+			},
+		},
+		"Throttler deadline should not panic on zero capacity": {
+			tms: 3,
+			thr: NewThrottlerDeadline(0, 0.5),
+			tss: []time.Duration{
+				-ms5_0,
+				-ms5_0,
+				-ms5_0,
+			},
+		},
 		"Throttler monitor should throttle on internal stats error": {
 			tms: 3,
 			thr: NewThrottlerMonitor(
@@ -833,6 +912,43 @@ func TestThrottlers(t *testing.T) {
 				nil,
 			},
 		},
+		"Throttler shaping should wait for timed throttler hint": {
+			tms: 3,
+			thr: NewThrottlerShaping(NewThrottlerTimed(1, ms10_0, 0), ms1_0, ms1_0),
+			durs: []time.Duration{
+				0,
+				ms10_0,
+				ms30_0,
+			},
+		},
+		"Throttler shaping should backoff on throttler without hint": {
+			tms: 1,
+			thr: NewThrottlerShaping(NewThrottlerBefore(3), ms1_0, ms10_0),
+			durs: []time.Duration{
+				ms7_0,
+			},
+		},
+		"Throttler shaping should backoff on zero initial duration": {
+			tms: 1,
+			thr: NewThrottlerShaping(NewThrottlerBefore(3), 0, 0),
+			durs: []time.Duration{
+				ms2_0,
+			},
+		},
+		"Throttler shaping should throttle on canceled context": {
+			tms: 3,
+			thr: NewThrottlerShaping(NewThrottlerEcho(errors.New("test")), ms1_0, ms1_0),
+			ctxs: []context.Context{
+				cctx,
+				cctx,
+				cctx,
+			},
+			errs: []error{
+				errors.New("test"),
+				errors.New("test"),
+				errors.New("test"),
+			},
+		},
 		"Throttler cache should not throttle on cached throttler": {
 			tms: 3,
 			thr: NewThrottlerCache(NewThrottlerAfter(1), ms30_0),
@@ -867,6 +983,100 @@ func TestThrottlers(t *testing.T) {
 				errors.New("throttler has exceed threshold"),
 			},
 		},
+		"Throttler switch should not throttle on pass state": {
+			tms: 3,
+			thr: NewThrottlerSwitch(swtmock{state: "pass"}),
+		},
+		"Throttler switch should throttle on reject state": {
+			tms: 3,
+			thr: NewThrottlerSwitch(swtmock{state: "reject"}),
+			errs: []error{
+				errors.New("throttler has been switched to reject"),
+				errors.New("throttler has been switched to reject"),
+				errors.New("throttler has been switched to reject"),
+			},
+		},
+		"Throttler switch should throttle on reject state with custom message": {
+			tms: 3,
+			thr: NewThrottlerSwitch(swtmock{state: "reject  maintenance window\n"}),
+			errs: []error{
+				errors.New("maintenance window"),
+				errors.New("maintenance window"),
+				errors.New("maintenance window"),
+			},
+		},
+		"Throttler switch should throttle on pattern state with matching key": {
+			tms: 3,
+			thr: NewThrottlerSwitch(swtmock{state: "pattern ^batch"}),
+			ctxs: []context.Context{
+				WithKey(context.Background(), "batch-import"),
+				WithKey(context.Background(), "api"),
+				WithKey(context.Background(), "batch-export"),
+			},
+			errs: []error{
+				errors.New("throttler has been switched to reject key"),
+				nil,
+				errors.New("throttler has been switched to reject key"),
+			},
+		},
+		"Throttler switch should not throttle on invalid state": {
+			tms: 3,
+			thr: NewThrottlerSwitch(swtmock{state: "drop"}),
+		},
+		"Throttler switch should throttle on internal error": {
+			tms: 1,
+			thr: NewThrottlerSwitch(swtmock{err: errors.New("test")}),
+			errs: []error{
+				fmt.Errorf("throttler hasn't found any switch state %w", errors.New("test")),
+			},
+		},
+		"Throttler drain should not throttle before drain": {
+			tms: 3,
+			thr: NewThrottlerDrain(),
+		},
+		"Throttler drain should throttle after drain": {
+			tms: 3,
+			thr: func() Throttler {
+				thr := NewThrottlerDrain()
+				thr.Drain()
+				return thr
+			}(),
+			errs: []error{
+				ErrDrained,
+				ErrDrained,
+				ErrDrained,
+			},
+		},
+		"Throttler shadow should not throttle on candidate throttling": {
+			tms: 3,
+			thr: NewThrottlerShadow(NewThrottlerEcho(nil), NewThrottlerEcho(errors.New("test")), 1),
+		},
+		"Throttler shadow should not throttle on candidate panic": {
+			tms: 3,
+			thr: NewThrottlerShadow(NewThrottlerEcho(nil), NewThrottlerPanic(), 1),
+		},
+		"Throttler canary should not throttle on zero percentage": {
+			tms: 3,
+			thr: NewThrottlerCanary(NewThrottlerEcho(nil), NewThrottlerEcho(errors.New("test")), 0.0),
+		},
+		"Throttler canary should throttle on full percentage": {
+			tms: 3,
+			thr: NewThrottlerCanary(NewThrottlerEcho(nil), NewThrottlerEcho(errors.New("test")), 1.1),
+			errs: []error{
+				errors.New("test"),
+				errors.New("test"),
+				errors.New("test"),
+			},
+		},
+		"Throttler shadow should throttle on enforced throttling": {
+			tms: 3,
+			thr: NewThrottlerShadow(NewThrottlerAfter(1), NewThrottlerEcho(nil), 1),
+			errs: []error{
+				nil,
+				errors.New("throttler has exceed threshold"),
+				errors.New("throttler has exceed threshold"),
+			},
+		},
 	}
 	for tname, ptrtcase := range table {
 		t.Run(tname, func(t *testing.T) {
@@ -893,6 +1103,390 @@ func TestThrottlers(t *testing.T) {
 	}
 }
 
+func TestThrottlerShadow(t *testing.T) {
+	thr := NewThrottlerShadow(NewThrottlerAfter(2), NewThrottlerRunning(1), 2)
+	for i, key := range []string{"a", "b", "c", "d"} {
+		_ = thr.Acquire(WithKey(context.Background(), key))
+		// wait for candidate evaluation to keep calls order
+		evaluated(t, thr, uint64(i+1))
+	}
+	require.Equal(t, map[string]interface{}{
+		"calls":     uint64(4),
+		"throttled": uint64(1),
+		"admitted":  uint64(0),
+		"samples":   []string{"b"},
+	}, thr.(Tunable).Meta())
+}
+
+func TestThrottlerShadowCandidate(t *testing.T) {
+	ctx := context.Background()
+	// blocking candidate never delays calls
+	thr := NewThrottlerShadow(NewThrottlerEcho(nil), NewThrottlerWait(ms30_0), 1)
+	ts := time.Now()
+	require.NoError(t, thr.Acquire(ctx))
+	require.Less(t, int64(time.Since(ts)), int64(ms5_0))
+	require.NoError(t, thr.Release(ctx))
+	evaluated(t, thr, 1)
+	require.Equal(t, uint64(1), thr.(Tunable).Meta()["throttled"])
+	// candidate is released once both evaluation and call are finished
+	candidate := NewThrottlerBuffered(1)
+	thr = NewThrottlerShadow(NewThrottlerEcho(nil), candidate, 1)
+	first, err := AcquireTicket(ctx, thr)
+	require.NoError(t, err)
+	evaluated(t, thr, 1)
+	ts = time.Now()
+	second, err := AcquireTicket(ctx, thr)
+	require.NoError(t, err)
+	require.Less(t, int64(time.Since(ts)), int64(ms5_0))
+	evaluated(t, thr, 2)
+	require.Equal(t, uint64(1), thr.(Tunable).Meta()["throttled"])
+	require.NoError(t, second.Release(ctx))
+	require.NoError(t, first.Release(ctx))
+	require.NoError(t, <-AcquireAsync(ctx, candidate))
+	require.NoError(t, candidate.Release(ctx))
+	// plain releases are matched in order including calls not decided in time
+	require.NoError(t, thr.Acquire(ctx))
+	evaluated(t, thr, 3)
+	require.NoError(t, thr.Acquire(ctx))
+	evaluated(t, thr, 4)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-AcquireAsync(ctx, candidate))
+	require.NoError(t, candidate.Release(ctx))
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-AcquireAsync(ctx, candidate))
+}
+
+func evaluated(t *testing.T, thr Throttler, calls uint64) {
+	require.Eventually(t, func() bool {
+		return thr.(Tunable).Meta()["calls"] == calls
+	}, time.Second, time.Millisecond)
+}
+
+func TestThrottlerCanary(t *testing.T) {
+	current, candidate := NewThrottlerRunning(1), NewThrottlerRunning(1)
+	thr := NewThrottlerCanary(current, candidate, 0.5)
+	ctxs := make([]context.Context, 0, 100)
+	for i := 0; i < 100; i++ {
+		ctx := WithKey(context.Background(), fmt.Sprintf("key %d", i))
+		ctxs = append(ctxs, ctx)
+		_ = thr.Acquire(ctx)
+	}
+	meta := thr.(Tunable).Meta()
+	require.Equal(t, uint64(100), meta["current"].(uint64)+meta["candidate"].(uint64))
+	require.NotZero(t, meta["current"])
+	require.NotZero(t, meta["candidate"])
+	require.NoError(t, thr.(Tunable).Tune("percentage", 1.0))
+	for _, ctx := range ctxs {
+		require.NoError(t, thr.Release(ctx))
+	}
+	require.Equal(t, uint64(0), current.(Tunable).Meta()["running"])
+	require.Equal(t, uint64(0), candidate.(Tunable).Meta()["running"])
+}
+
+func TestThrottlerTimedReservations(t *testing.T) {
+	ctx := context.Background()
+	thr := NewThrottlerTimed(2, ms10_0, 0)
+	rsv := thr.(Reserver)
+	_, err := rsv.Reserve(ctx, 3)
+	require.Equal(t, fmt.Errorf("throttler can't reserve %d above threshold", 3), err)
+	// reserve available quota now
+	now, err := rsv.Reserve(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, ms0_0, now.Delay())
+	// reserve quota for the next interval
+	later, err := rsv.Reserve(ctx, 2)
+	require.NoError(t, err)
+	require.Less(t, int64(ms5_0), int64(later.Delay()))
+	require.LessOrEqual(t, int64(later.Delay()), int64(ms10_0))
+	// reserved quota throttles online calls
+	require.Equal(t, errors.New("throttler has exceed threshold"), thr.Acquire(ctx))
+	// canceled reservation returns the quota back
+	later.Cancel()
+	later.Cancel()
+	require.NoError(t, thr.Acquire(ctx))
+	now.Cancel()
+	require.Equal(t, errors.New("throttler has exceed threshold"), thr.Acquire(ctx))
+	// reserved quota is available after the interval
+	later, err = rsv.Reserve(ctx, 2)
+	require.NoError(t, err)
+	time.Sleep(later.Delay())
+	require.Equal(t, ms0_0, later.Delay())
+	// reserved quota is used during the next interval
+	require.Equal(t, errors.New("throttler has exceed threshold"), thr.Acquire(ctx))
+	time.Sleep(ms10_0 + ms3_0)
+	require.NoError(t, thr.Acquire(ctx))
+}
+
+func TestThrottlerTimedReservationsCancel(t *testing.T) {
+	ctx := context.Background()
+	thr := NewThrottlerTimed(2, ms30_0, ms10_0)
+	rsv := thr.(Reserver)
+	_, err := rsv.Reserve(ctx, 2)
+	require.NoError(t, err)
+	later, err := rsv.Reserve(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), thr.(Tunable).Meta()["current"])
+	// one running quota update pays part of the reserved quota
+	time.Sleep(ms10_0 + ms3_0)
+	require.Less(t, int64(ms0_0), int64(later.Delay()))
+	require.Equal(t, uint64(3), thr.(Tunable).Meta()["current"])
+	// canceled reservation returns back only not yet paid quota
+	later.Cancel()
+	require.Equal(t, uint64(2), thr.(Tunable).Meta()["current"])
+	require.Equal(t, errors.New("throttler has exceed threshold"), thr.Acquire(ctx))
+}
+
+func TestThrottlerAcquireAsync(t *testing.T) {
+	pending := func(done <-chan error) bool {
+		select {
+		case <-done:
+			return false
+		default:
+			return true
+		}
+	}
+	ctx := context.Background()
+	thr := NewThrottlerBuffered(1)
+	require.NoError(t, <-AcquireAsync(ctx, thr))
+	second := AcquireAsync(ctx, thr)
+	cctx, cancel := context.WithCancel(ctx)
+	third := AcquireAsync(cctx, thr)
+	fourth := AcquireAsync(ctx, thr)
+	require.True(t, pending(second))
+	cancel()
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-second)
+	require.True(t, pending(third))
+	require.True(t, pending(fourth))
+	require.NoError(t, thr.Release(ctx))
+	require.Equal(t, context.Canceled, <-third)
+	require.NoError(t, <-fourth)
+	require.Equal(t, context.Canceled, <-AcquireAsync(cctx, thr))
+	// waiters are removed right away once their context is done
+	for _, thr := range []Throttler{NewThrottlerBuffered(1), NewThrottlerPriority(1, 2)} {
+		require.NoError(t, thr.Acquire(ctx))
+		cctx, cancel := context.WithCancel(ctx)
+		waiter := AcquireAsync(cctx, thr)
+		require.True(t, pending(waiter))
+		cancel()
+		require.Equal(t, context.Canceled, <-waiter)
+		tctx, tcancel := context.WithTimeout(ctx, ms1_0)
+		require.Equal(t, context.DeadlineExceeded, thr.Acquire(tctx))
+		tcancel()
+		require.NoError(t, thr.Release(ctx))
+		require.NoError(t, <-AcquireAsync(ctx, thr))
+	}
+	pthr := NewThrottlerPriority(2, 1)
+	require.NoError(t, <-AcquireAsync(ctx, pthr))
+	require.NoError(t, <-AcquireAsync(ctx, pthr))
+	third = AcquireAsync(ctx, pthr)
+	require.True(t, pending(third))
+	require.NoError(t, pthr.Release(ctx))
+	require.NoError(t, <-third)
+	require.Equal(t, errors.New("test"), <-AcquireAsync(ctx, NewThrottlerEcho(errors.New("test"))))
+}
+
+func TestThrottlerQueue(t *testing.T) {
+	table := map[string]struct {
+		threshold  uint64
+		discipline Discipline
+		order      []int
+	}{
+		"Throttler queue should admit calls in fifo order": {
+			threshold:  1,
+			discipline: DisciplineFIFO,
+			order:      []int{0, 1, 2},
+		},
+		"Throttler queue should admit calls in lifo order": {
+			threshold:  1,
+			discipline: DisciplineLIFO,
+			order:      []int{2, 1, 0},
+		},
+		"Throttler queue should admit calls in fifo order without overload": {
+			threshold:  4,
+			discipline: DisciplineAdaptiveLIFO,
+			order:      []int{0, 1, 2},
+		},
+		"Throttler queue should admit calls in lifo order on overload": {
+			threshold:  3,
+			discipline: DisciplineAdaptiveLIFO,
+			order:      []int{2, 0, 1},
+		},
+		"Throttler queue should admit calls in earliest deadline order": {
+			threshold:  1,
+			discipline: DisciplineEDF,
+			order:      []int{2, 0, 1},
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			ctx := context.Background()
+			thr := NewThrottlerQueue(tcase.threshold, tcase.discipline)
+			for i := uint64(0); i < tcase.threshold; i++ {
+				require.NoError(t, thr.Acquire(ctx))
+			}
+			lctx, lcancel := context.WithTimeout(ctx, ms30_0)
+			defer lcancel()
+			ectx, ecancel := context.WithTimeout(ctx, ms10_0)
+			defer ecancel()
+			waiters := []<-chan error{
+				AcquireAsync(lctx, thr),
+				AcquireAsync(ctx, thr),
+				AcquireAsync(ectx, thr),
+			}
+			order := make([]int, 0, len(waiters))
+			for range waiters {
+				require.NoError(t, thr.Release(ctx))
+				for i, w := range waiters {
+					select {
+					case err := <-w:
+						require.NoError(t, err)
+						order = append(order, i)
+					default:
+					}
+				}
+			}
+			require.Equal(t, tcase.order, order)
+		})
+	}
+}
+
+func TestThrottlerQueueSkip(t *testing.T) {
+	ctx := context.Background()
+	for _, thr := range []Throttler{
+		NewThrottlerQueue(1, DisciplineEDF),
+		NewThrottlerPriorityQueue(1, 2, DisciplineEDF),
+	} {
+		require.NoError(t, thr.Acquire(ctx))
+		ectx, cancel := context.WithTimeout(ctx, ms1_0)
+		defer cancel()
+		expired := make(chan error, 1)
+		go func() {
+			expired <- thr.Acquire(ectx)
+		}()
+		time.Sleep(ms2_0)
+		lctx, lcancel := context.WithTimeout(ctx, ms30_0)
+		defer lcancel()
+		live := AcquireAsync(lctx, thr)
+		require.NoError(t, thr.Release(ctx))
+		require.Equal(t, context.DeadlineExceeded, <-expired)
+		require.NoError(t, <-live)
+		// skipped calls are not recorded by tickets
+		tkt, err := AcquireTicket(ectx, thr)
+		require.Equal(t, context.DeadlineExceeded, err)
+		require.NoError(t, tkt.Release(ctx))
+		require.NoError(t, thr.Release(ctx))
+		require.NoError(t, <-AcquireAsync(ctx, thr))
+	}
+	// waiting calls of the same level are admitted by the discipline
+	thr := NewThrottlerPriorityQueue(1, 1, DisciplineLIFO)
+	require.NoError(t, thr.Acquire(ctx))
+	first := AcquireAsync(ctx, thr)
+	second := AcquireAsync(ctx, thr)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-second)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-first)
+}
+
+func TestThrottlerDeadline(t *testing.T) {
+	ctx := context.Background()
+	thr := NewThrottlerDeadline(10, 0.5)
+	tctx, cancel := context.WithTimeout(ctx, ms1_0)
+	defer cancel()
+	// no latency is known yet
+	require.NoError(t, thr.Acquire(tctx))
+	require.NoError(t, thr.Release(WithTimestamp(ctx, time.Now().Add(-ms5_0))))
+	require.NoError(t, thr.Acquire(ctx))
+	require.Equal(t, errors.New("throttler has predicted deadline miss"), thr.Acquire(tctx))
+	lctx, lcancel := context.WithTimeout(ctx, ms30_0)
+	defer lcancel()
+	require.NoError(t, thr.Acquire(lctx))
+	require.Equal(t, 1, thr.(Tunable).Meta()["latencies"])
+	require.NoError(t, thr.(Tunable).Tune("percentile", 0.9))
+	require.Error(t, thr.(Tunable).Tune("percentile", 1.5))
+	thr.(Tunable).Reset()
+	require.NoError(t, thr.Acquire(tctx))
+	// releases without timestamp are not kept
+	require.NoError(t, thr.Release(ctx))
+	require.Equal(t, 0, thr.(Tunable).Meta()["latencies"])
+	// rejected calls are not kept with tickets
+	require.NoError(t, thr.Release(WithTimestamp(ctx, time.Now().Add(-ms5_0))))
+	tkt, err := AcquireTicket(WithTimestamp(tctx, time.Now()), thr)
+	require.Equal(t, errors.New("throttler has predicted deadline miss"), err)
+	require.NoError(t, tkt.Release(WithTimestamp(tctx, time.Now())))
+	require.Equal(t, 1, thr.(Tunable).Meta()["latencies"])
+	require.Equal(t, errors.New("throttler has predicted deadline miss"), thr.Acquire(tctx))
+	r := NewRunnerSync(context.Background(), thr)
+	r.RunContext(WithTimestamp(tctx, time.Now()), nope)
+	require.Error(t, r.Result())
+	require.Equal(t, 1, thr.(Tunable).Meta()["latencies"])
+}
+
+func TestThrottlerWaiterRevoke(t *testing.T) {
+	cctx, cancel := context.WithCancel(context.Background())
+	w := newWaiter(cctx)
+	cancel()
+	// running quota is granted together with the context being done
+	w.dispatch(nil)
+	var released uint64
+	w.watch(func(*waiter) bool {
+		return false
+	}, func(context.Context) error {
+		atomicIncr(&released)
+		return nil
+	})
+	require.Equal(t, uint64(1), atomicGet(&released))
+	require.Equal(t, context.Canceled, <-w.done)
+}
+
+func TestThrottlerPriority(t *testing.T) {
+	ctx := context.Background()
+	low, high := WithPriority(ctx, 1), WithPriority(ctx, 2)
+	// no level is left without running quota
+	thr := NewThrottlerPriority(1, 10)
+	require.NoError(t, thr.Acquire(low))
+	require.NoError(t, thr.Release(low))
+	// waiting calls are admitted by weight
+	thr = NewThrottlerPriority(1, 2)
+	require.NoError(t, thr.Acquire(low))
+	first := AcquireAsync(low, thr)
+	second := AcquireAsync(high, thr)
+	third := AcquireAsync(high, thr)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-second)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-first)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-third)
+	require.NoError(t, thr.Release(ctx))
+	// levels are not limited by uint8
+	thr = NewThrottlerPriority(1, 1000)
+	require.NoError(t, thr.Acquire(low))
+	first = AcquireAsync(low, thr)
+	second = AcquireAsync(WithPriority(ctx, 1000), thr)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-second)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-first)
+	require.NoError(t, thr.Release(ctx))
+	// starving calls age upward
+	aging := DefaultPriorityAging
+	DefaultPriorityAging = ms1_0
+	defer func() {
+		DefaultPriorityAging = aging
+	}()
+	thr = NewThrottlerPriority(1, 2)
+	require.NoError(t, thr.Acquire(high))
+	first = AcquireAsync(low, thr)
+	time.Sleep(ms5_0)
+	second = AcquireAsync(high, thr)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-first)
+	require.NoError(t, thr.Release(ctx))
+	require.NoError(t, <-second)
+}
+
 func BenchmarkComplexThrottlers(b *testing.B) {
 	thr := NewThrottlerAll(
 		NewThrottlerAny(
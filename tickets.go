@@ -0,0 +1,245 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Ticket defines exact record of throttling quota taken by single `AcquireTicket` call.
+type Ticket interface {
+	// Release puts back exactly the throttling quota recorded by the ticket
+	// or returns error if this is not possible.
+	// Ticket is released only once, all subsequent releases are ignored.
+	Release(context.Context) error
+}
+
+// ticketer defines optional composite throttler extension
+// that records into ticket exactly which child throttlers were acquired.
+type ticketer interface {
+	acquireTicket(context.Context, *ticket) error
+}
+
+type ticket struct {
+	releases []Runnable
+	rejected Throttler
+	released uint64
+	lock     sync.Mutex
+}
+
+// AcquireTicket takes a part of throttling quota from the provided throttler
+// same way as `Acquire` does and returns error if throttling quota is drained,
+// but also returns ticket that records exactly which leaf throttlers were acquired.
+// Ticket is returned even if throttling quota is drained and needs to be released anyway,
+// releasing the ticket puts back exactly the throttling quota that was taken
+// regardless of composite throttlers routing state changes in between.
+// Leaf throttlers are always recorded once their `Acquire` was called,
+// builtin composite throttlers record only the child throttlers they actually acquired.
+// Builtin throttlers that take no quota for rejected calls, like `drain`, `deadline`, `lease`,
+// `buffered`, `queue` and `priority`, record only admitted calls, so their rejected tickets release nothing.
+func AcquireTicket(ctx context.Context, thr Throttler) (Ticket, error) {
+	t := &ticket{}
+	err := t.acquire(ctx, thr)
+	return t, err
+}
+
+func (t *ticket) Release(ctx context.Context) (err error) {
+	if atomicBIncr(&t.released) > 1 {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	// release in reverse acquire order
+	for i := len(t.releases) - 1; i >= 0; i-- {
+		if rerr := t.releases[i](ctx); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+func (t *ticket) acquire(ctx context.Context, thr Throttler) (err error) {
+	defer func() {
+		if err != nil {
+			t.reject(thr)
+		}
+	}()
+	if tthr, ok := thr.(ticketer); ok {
+		return tthr.acquireTicket(ctx, t)
+	}
+	// leaf throttlers don't know anything about tickets
+	// so ticket needs to be hidden from their context
+	err = thr.Acquire(withTicket(ctx, nil))
+	t.add(thr.Release)
+	return err
+}
+
+// admit records the provided release only if the provided acquire admits the call,
+// it is used by throttlers that take no quota for rejected calls.
+func (t *ticket) admit(ctx context.Context, acquire Runnable, release Runnable) error {
+	if err := acquire(ctx); err != nil {
+		return err
+	}
+	t.add(release)
+	return nil
+}
+
+func (t *ticket) add(release Runnable) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.releases = append(t.releases, release)
+}
+
+// reject records the innermost throttler that returned error,
+// inner throttlers always return before outer ones.
+func (t *ticket) reject(thr Throttler) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.rejected == nil {
+		t.rejected = thr
+	}
+}
+
+// rejecter returns the recorded rejecting throttler or the provided root throttler.
+func (t *ticket) rejecter(root Throttler) Throttler {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.rejected == nil {
+		return root
+	}
+	return t.rejected
+}
+
+func (thrs tall) acquireTicket(ctx context.Context, t *ticket) error {
+	for _, thr := range thrs {
+		if err := t.acquire(ctx, thr); err == nil {
+			return nil
+		}
+	}
+	if len(thrs) > 0 {
+		return errors.New("throttler has received internal errors")
+	}
+	return nil
+}
+
+func (thrs tany) acquireTicket(ctx context.Context, t *ticket) error {
+	runs := make([]Runnable, 0, len(thrs))
+	for _, thr := range thrs {
+		thr := thr
+		runs = append(runs, func(ctx context.Context) error {
+			if err := t.acquire(ctx, thr); err != nil {
+				return errors.New("throttler has received internal errors")
+			}
+			return nil
+		})
+	}
+	return all(runs...)(ctx)
+}
+
+func (thr tpattern) acquireTicket(ctx context.Context, t *ticket) error {
+	for _, pattern := range thr {
+		if key := ctxKey(ctx); pattern.Pattern.MatchString(key) {
+			return t.acquire(ctx, pattern.Throttler)
+		}
+	}
+	return errors.New("throttler hasn't found any key")
+}
+
+func (thr *tring) acquireTicket(ctx context.Context, t *ticket) error {
+	if length := len(thr.thrs); length > 0 {
+		acquire := atomicIncr(&thr.acquire) - 1
+		index := int(acquire) % length
+		return t.acquire(ctx, thr.thrs[index])
+	}
+	return errors.New("throttler hasn't found any index")
+}
+
+func (thr tnot) acquireTicket(ctx context.Context, t *ticket) error {
+	if err := t.acquire(ctx, thr.thr); err != nil {
+		return nil
+	}
+	return errors.New("throttler hasn't received any internal error")
+}
+
+func (thr tsuppress) acquireTicket(ctx context.Context, t *ticket) error {
+	if err := t.acquire(ctx, thr.thr); err != nil {
+		log("throttler error is suppressed %v", err)
+	}
+	return nil
+}
+
+func (thr tretry) acquireTicket(ctx context.Context, t *ticket) error {
+	return retried(thr.retries, func(ctx context.Context) error {
+		return t.acquire(ctx, thr.thr)
+	})(ctx)
+}
+
+func (thr tshaping) acquireTicket(ctx context.Context, t *ticket) error {
+	var current *ticket
+	err := thr.shape(ctx, func(ctx context.Context) error {
+		current = &ticket{}
+		return current.acquire(ctx, thr.thr)
+	}, func(ctx context.Context) error {
+		return current.Release(ctx)
+	})
+	t.add(current.Release)
+	return err
+}
+
+func (thr *tdrain) acquireTicket(ctx context.Context, t *ticket) error {
+	return t.admit(ctx, thr.acquire, thr.release)
+}
+
+func (thr tdeadline) acquireTicket(ctx context.Context, t *ticket) error {
+	return t.admit(ctx, thr.Acquire, thr.Release)
+}
+
+func (thr *tbuffered) acquireTicket(ctx context.Context, t *ticket) error {
+	return t.admit(ctx, thr.Acquire, thr.Release)
+}
+
+func (thr *tpriority) acquireTicket(ctx context.Context, t *ticket) error {
+	return t.admit(ctx, thr.Acquire, thr.Release)
+}
+
+func (thr tcache) acquireTicket(ctx context.Context, t *ticket) error {
+	// cached acquire records underlying throttler
+	// only if it was actually called
+	err := thr.acquire(withTicket(ctx, t))
+	t.add(thr.reset)
+	return err
+}
+
+func (thr *tadaptive) acquireTicket(ctx context.Context, t *ticket) error {
+	if err := t.acquire(ctx, thr.thr); err != nil {
+		atomicBSub(&thr.ttimed.threshold, thr.step*thr.step)
+	} else {
+		atomicBAdd(&thr.ttimed.threshold, thr.step)
+	}
+	return t.acquire(ctx, thr.ttimed)
+}
+
+func (thr *tshadow) acquireTicket(ctx context.Context, t *ticket) error {
+	err := t.acquire(ctx, thr.thr)
+	sc := thr.evaluate(ctx, err)
+	t.add(func(context.Context) error {
+		thr.finish(sc)
+		return nil
+	})
+	return err
+}
+
+func (thr *tcanary) acquireTicket(ctx context.Context, t *ticket) error {
+	index := thr.route(ctxKey(ctx))
+	atomicIncr(&thr.calls[index])
+	return t.acquire(ctx, thr.thrs[index])
+}
+
+func (thr *tadmin) acquireTicket(ctx context.Context, t *ticket) error {
+	// bypassed acquire records nothing in the ticket
+	// so its release never touches underlying throttler
+	_, err := thr.acquire(ctx, func(ctx context.Context) error {
+		return t.acquire(ctx, thr.thr)
+	})
+	return err
+}
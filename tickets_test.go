@@ -0,0 +1,142 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTickets(t *testing.T) {
+	DefaultRetriedDuration = time.Millisecond
+	running := func() []Throttler {
+		return []Throttler{NewThrottlerRunning(0), NewThrottlerRunning(1), NewThrottlerRunning(1)}
+	}
+	table := map[string]struct {
+		thr  func(thrs []Throttler) Throttler
+		tms  int
+		errs []error
+	}{
+		"Ticket all should release all tried throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerAll(thrs...)
+			},
+			tms: 3,
+			errs: []error{
+				nil,
+				nil,
+				errors.New("throttler has received internal errors"),
+			},
+		},
+		"Ticket any should release all throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerAny(thrs[1:]...)
+			},
+			tms: 2,
+			errs: []error{
+				nil,
+				errors.New("throttler has received internal errors"),
+			},
+		},
+		"Ticket ring should release acquired throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerRing(thrs[1:]...)
+			},
+			tms: 3,
+			errs: []error{
+				nil,
+				nil,
+				errors.New("throttler has exceed running threshold"),
+			},
+		},
+		"Ticket pattern should release matched throttler": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerPattern(
+					Pattern{Pattern: regexp.MustCompile("unknown"), Throttler: thrs[0]},
+					Pattern{Pattern: regexp.MustCompile("test"), Throttler: thrs[1]},
+				)
+			},
+			tms: 2,
+			errs: []error{
+				nil,
+				errors.New("throttler has exceed running threshold"),
+			},
+		},
+		"Ticket retry should release all retried throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerRetry(thrs[0], 2)
+			},
+			tms: 1,
+			errs: []error{
+				errors.New("throttler has exceed running threshold"),
+			},
+		},
+		"Ticket shaping should release all throttled throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerShaping(NewThrottlerAll(thrs[0], NewThrottlerBefore(2)), time.Millisecond, time.Millisecond)
+			},
+			tms: 1,
+			errs: []error{
+				nil,
+			},
+		},
+		"Ticket cache should release cached throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerCache(NewThrottlerAll(thrs...), time.Minute)
+			},
+			tms: 3,
+			errs: []error{
+				nil,
+				nil,
+				nil,
+			},
+		},
+		"Ticket shadow should release both throttlers": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerShadow(thrs[1], NewThrottlerSuppress(thrs[0]), 0)
+			},
+			tms: 2,
+			errs: []error{
+				nil,
+				errors.New("throttler has exceed running threshold"),
+			},
+		},
+		"Ticket canary should release acquired throttler": {
+			thr: func(thrs []Throttler) Throttler {
+				return NewThrottlerCanary(thrs[1], thrs[2], 1.0)
+			},
+			tms: 2,
+			errs: []error{
+				nil,
+				errors.New("throttler has exceed running threshold"),
+			},
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			thrs := running()
+			thr := tcase.thr(thrs)
+			ctx := WithKey(context.Background(), "test")
+			tickets := make([]Ticket, 0, tcase.tms)
+			for i := 0; i < tcase.tms; i++ {
+				ticket, err := AcquireTicket(ctx, thr)
+				assert.Equal(t, tcase.errs[i], err)
+				tickets = append(tickets, ticket)
+			}
+			// change routing state in between acquire and release
+			if tunable, ok := thr.(Tunable); ok {
+				_ = tunable.Tune("percentage", 0.0)
+			}
+			for _, ticket := range tickets {
+				assert.NoError(t, ticket.Release(ctx))
+				assert.NoError(t, ticket.Release(ctx))
+			}
+			for _, thr := range thrs {
+				assert.Equal(t, uint64(0), thr.(Tunable).Meta()["running"])
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package gohalt
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Timeout defines adaptive per call timeout
+// that limits each wrapped `Runnable` with context deadline.
+type Timeout interface {
+	// Wrap returns `Runnable` that runs the provided `Runnable`
+	// with context deadline defined by the current timeout.
+	Wrap(Runnable) Runnable
+	// Timeout returns the current timeout.
+	Timeout() time.Duration
+}
+
+type tmadaptive struct {
+	latencies  *percentiles
+	percentile float64
+	multiplier float64
+	min        time.Duration
+	max        time.Duration
+}
+
+// NewTimeoutAdaptive creates new adaptive timeout instance
+// that derives each call timeout from the latency percentile defined by the specified percentile
+// multiplied by the specified multiplier and bounded by the specified min and max durations.
+// Latencies of all finished calls are kept in bounded buffer with capacity c defined by the specified capacity
+// same way as `percentile` throttler does, until any latency is observed max duration is used.
+// Timed out calls are kept as the applied timeout latency, so with multiplier above 1
+// timeout adapts upward once real latency rises above it.
+// Calls canceled by the caller context are not kept.
+func NewTimeoutAdaptive(
+	capacity uint8,
+	percentile float64,
+	multiplier float64,
+	min time.Duration,
+	max time.Duration,
+) Timeout {
+	percentile = math.Abs(percentile)
+	if percentile > 1.0 {
+		percentile = 1.0
+	}
+	if max < min {
+		max = min
+	}
+	latencies := &percentiles{cap: capacity}
+	latencies.Prune()
+	return &tmadaptive{
+		latencies:  latencies,
+		percentile: percentile,
+		multiplier: math.Abs(multiplier),
+		min:        min,
+		max:        max,
+	}
+}
+
+func (tm *tmadaptive) Wrap(run Runnable) Runnable {
+	return func(pctx context.Context) error {
+		timeout := tm.Timeout()
+		ctx, cancel := context.WithTimeout(pctx, timeout)
+		defer cancel()
+		ts := time.Now().UTC()
+		err := run(ctx)
+		latency := time.Since(ts)
+		switch {
+		// calls canceled by the caller don't tell anything about latency
+		case pctx.Err() != nil:
+			return err
+		// timed out calls are kept as censored latency of the applied timeout
+		// so timeout could adapt upward once real latency rises above it
+		case errors.Is(ctx.Err(), context.DeadlineExceeded) && latency < timeout:
+			latency = timeout
+		}
+		tm.latencies.Push(uint64(latency))
+		return err
+	}
+}
+
+func (tm *tmadaptive) Timeout() time.Duration {
+	if tm.latencies.Len() == 0 {
+		return tm.max
+	}
+	timeout := time.Duration(float64(tm.latencies.At(tm.percentile)) * tm.multiplier)
+	switch {
+	case timeout < tm.min:
+		return tm.min
+	case timeout > tm.max:
+		return tm.max
+	default:
+		return timeout
+	}
+}
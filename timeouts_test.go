@@ -0,0 +1,105 @@
+package gohalt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeouts(t *testing.T) {
+	table := map[string]struct {
+		tm       Timeout
+		lats     []time.Duration
+		timeout  time.Duration
+		deadline time.Duration
+		err      error
+	}{
+		"Adaptive timeout should use max timeout without latencies": {
+			tm:      NewTimeoutAdaptive(10, 0.5, 2.0, ms1_0, ms10_0),
+			timeout: ms10_0,
+		},
+		"Adaptive timeout should use percentile latency multiplied by multiplier": {
+			tm:      NewTimeoutAdaptive(10, 1.0, 3.0, ms1_0, ms30_0),
+			lats:    []time.Duration{ms1_0, ms3_0},
+			timeout: ms9_0,
+		},
+		"Adaptive timeout should keep only last latency on zero capacity": {
+			tm:      NewTimeoutAdaptive(0, 1.0, 3.0, ms1_0, ms30_0),
+			lats:    []time.Duration{ms5_0, ms1_0},
+			timeout: ms3_0,
+		},
+		"Adaptive timeout should use min timeout if latency is below min": {
+			tm:      NewTimeoutAdaptive(10, 0.5, 1.0, ms10_0, ms30_0),
+			lats:    []time.Duration{ms1_0},
+			timeout: ms10_0,
+		},
+		"Adaptive timeout should use max timeout if latency is above max": {
+			tm:      NewTimeoutAdaptive(10, 0.5, 10.0, ms1_0, ms10_0),
+			lats:    []time.Duration{ms2_0},
+			timeout: ms10_0,
+		},
+		"Adaptive timeout should cancel call after timeout": {
+			tm:       NewTimeoutAdaptive(10, 0.5, 1.0, ms1_0, ms2_0),
+			timeout:  ms2_0,
+			deadline: ms30_0,
+			err:      context.DeadlineExceeded,
+		},
+	}
+	for tname, tcase := range table {
+		t.Run(tname, func(t *testing.T) {
+			// observe latencies directly to keep timeouts precise
+			for _, lat := range tcase.lats {
+				tcase.tm.(*tmadaptive).latencies.Push(uint64(lat))
+			}
+			timeout := tcase.tm.Timeout()
+			assert.Equal(t, tcase.timeout, timeout)
+			if tcase.deadline > 0 {
+				err := tcase.tm.Wrap(func(ctx context.Context) error {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case <-time.After(tcase.deadline):
+						return nil
+					}
+				})(context.Background())
+				assert.Equal(t, tcase.err, err)
+			}
+		})
+	}
+}
+
+func TestTimeoutsAdaptUpward(t *testing.T) {
+	tm := NewTimeoutAdaptive(5, 0.5, 2.0, ms1_0, ms30_0)
+	fast := tm.Wrap(func(ctx context.Context) error {
+		return ctx.Err()
+	})
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, fast(context.Background()))
+	}
+	assert.Equal(t, ms1_0, tm.Timeout())
+	slow := tm.Wrap(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ms10_0):
+			return nil
+		}
+	})
+	var err error
+	for i := 0; i < 30 && tm.Timeout() < ms10_0+ms5_0; i++ {
+		if serr := slow(context.Background()); serr != nil {
+			err = serr
+		}
+	}
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.LessOrEqual(t, int64(ms10_0+ms5_0), int64(tm.Timeout()))
+	assert.NoError(t, slow(context.Background()))
+	// caller canceled calls are not kept
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeout := tm.Timeout()
+	assert.Equal(t, context.Canceled, fast(cctx))
+	assert.Equal(t, timeout, tm.Timeout())
+}